@@ -0,0 +1,24 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package monetdb
+
+import (
+	"github.com/MonetDB/MonetDB-Go/v2/mapi"
+)
+
+// Config contains the settings of a connection to a MonetDB server. It can
+// be used with NewConnector to open a database without a DSN.
+type Config = mapi.Config
+
+// NewConfig returns a Config with the default settings
+func NewConfig() *Config {
+	return mapi.NewConfig()
+}
+
+// ParseDSN creates a Config from a DSN. See the package documentation for
+// the supported formats.
+func ParseDSN(dsn string) (*Config, error) {
+	return mapi.ParseDSN(dsn)
+}
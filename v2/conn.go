@@ -8,69 +8,200 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/MonetDB/MonetDB-Go/v2/mapi"
 )
 
+// LocalTimePolicy tells how a time.Time argument in the local time zone of the
+// client, time.Local, is passed to the server.
+type LocalTimePolicy int
+
+const (
+	// LocalTimeInstant passes local times with their offset. The server converts
+	// them to the time zone of the session, the instant is kept. This is the default.
+	LocalTimeInstant LocalTimePolicy = iota
+	// LocalTimeWallClock passes local times as times in the time zone of the session,
+	// the date and the clock are kept. This suits applications that use local times
+	// for timestamp columns without a time zone.
+	LocalTimeWallClock
+)
+
 type Conn struct {
 	mapi *mapi.MapiConn
+	// The time zone of the session
 	timezone *time.Location
+	// The offset of the local time zone, when the driver set it in the session
+	offset int
+	// The prepared statements that are not closed yet
+	stmts map[*Stmt]struct{}
+	// How time.Time arguments in the local time zone are passed
+	localTimes LocalTimePolicy
+	// The converters that replace the built-in conversions
+	converters *mapi.Converters
 }
 
-func newConn(name string) (*Conn, error) {
+func newConn(ctx context.Context, config *Config, converters *mapi.Converters) (*Conn, error) {
 	conn := &Conn{
-		mapi: nil,
+		mapi:       nil,
+		stmts:      make(map[*Stmt]struct{}),
+		converters: converters,
 	}
 
-	// For now we do not change the timezone, because this might certain users.
-	// conn.timezone = time.Local
-	m, err := mapi.NewMapi(name)
+	m, err := mapi.NewMapiFromConfig(config)
 	if err != nil {
 		return conn, err
 	}
-	errConn := m.Connect()
+	errConn := m.ConnectContext(ctx)
 	if errConn != nil {
 		return conn, errConn
 	}
 
 	conn.mapi = m
 	m.SetSizeHeader(true)
-	conn.setServerTimezone()
+	// The time zone from the DSN is already set when the connection was made
+	if m.Timezone() != nil {
+		conn.timezone = m.Timezone()
+	} else if err := conn.setServerTimezone(); err != nil {
+		m.Disconnect()
+		conn.mapi = nil
+		return conn, err
+	}
 	return conn, nil
 }
 
+// setServerTimezone sets the time zone of the session to the current offset of the local
+// time zone of the client, when no time zone was configured.
 func (c *Conn) setServerTimezone() error {
-	tz, err := time.LoadLocation(c.timezone.String())
-	if err != nil {
+	_, offset := time.Now().Zone()
+	if err := c.mapi.SetTimezoneOffset(offset); err != nil {
 		return err
 	}
-	if tz.String() != c.timezone.String() {
-		return err
+	c.timezone = time.Local
+	c.offset = offset
+	return nil
+}
+
+// Timezone returns the time zone of the session. This is the configured time zone, or
+// the local time zone of the client when none was configured. Timestamps without a
+// time zone are only returned in the configured time zone, otherwise they are in UTC.
+func (c *Conn) Timezone() *time.Location {
+	return c.timezone
+}
+
+// SetLocalTimePolicy sets how time.Time arguments in the local time zone are passed to
+// the server. Use sql.Conn.Raw to reach the connection.
+func (c *Conn) SetLocalTimePolicy(policy LocalTimePolicy) {
+	c.localTimes = policy
+}
+
+// convertLocalTimes applies the local time policy to the arguments of a query
+func (c *Conn) convertLocalTimes(args []mapi.Value) []mapi.Value {
+	if c.localTimes != LocalTimeWallClock || c.timezone == nil {
+		return args
 	}
-	_, offset := time.Now().Zone()
+	loc := c.timezone
+	for i, arg := range args {
+		if t, ok := arg.(time.Time); ok && t.Location() == time.Local {
+			args[i] = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+		}
+	}
+	return args
+}
+
+// cancelQuery is called when the context of a query is done before the query finished. It
+// stops the query on the server, and waits until the goroutine that runs the query returns,
+// which is signalled by closing done. When the session id is not known, or the query cannot
+// be stopped in time, the network connection is interrupted and closed. The connection is
+// never left in an unknown state.
+func cancelQuery(m *mapi.MapiConn, sessionId int, done <-chan struct{}) {
+	if sessionId >= 0 {
+		if err := m.StopQuery(sessionId); err == nil {
+			select {
+			case <-done:
+				return
+			case <-time.After(mapi.StopTimeout):
+			}
+		}
+	}
+
+	m.Interrupt()
+	<-done
+	m.Disconnect()
+}
 
-	hours := int(offset / 3600)
-	remaining := offset - 3600 * hours
-	minutes := int(remaining / 60)
-	// Go does not have an absolute value function for int
-	if minutes < 0 {
-		minutes = -1 * minutes
+// checkBadConn converts the error of a command that did not reach the server to
+// driver.ErrBadConn. The sql package then retries the command on another connection.
+func checkBadConn(err error) error {
+	if errors.Is(err, mapi.ErrBadConn) {
+		return driver.ErrBadConn
 	}
-	query := fmt.Sprintf("SET TIME ZONE INTERVAL '%+03d:%02d' HOUR TO MINUTE;", hours, minutes)
-	err = executeStmt(c, query)
 	return err
 }
 
+// IsValid is called by the sql package before a connection is returned to the pool.
+// A connection that was broken by a network error, or that was closed because a
+// cancelled query could not be stopped, is removed from the pool.
+func (c *Conn) IsValid() bool {
+	return c.mapi != nil && c.mapi.IsConnected()
+}
+
+// Ping sends a lightweight command to the server. When the server does not answer before
+// the context is done, the connection is closed.
+func (c *Conn) Ping(ctx context.Context) error {
+	if c.mapi == nil {
+		return driver.ErrBadConn
+	}
+	return checkBadConn(c.mapi.Ping(ctx))
+}
+
+// ResetSession is called by the sql package before a connection from the pool is reused.
+// An open transaction is rolled back, and settings that were changed with statements like
+// SET SCHEMA, SET ROLE and SET TIME ZONE are restored. When that is not possible, the
+// connection is removed from the pool.
+func (c *Conn) ResetSession(ctx context.Context) error {
+	if c.mapi == nil {
+		return driver.ErrBadConn
+	}
+	changed := c.mapi.SessionChanged()
+	if err := c.mapi.ResetSession(); err != nil {
+		return driver.ErrBadConn
+	}
+	// The time zone is set by the driver when it was not configured. Its offset
+	// changes with daylight saving time.
+	if c.mapi.Timezone() == nil {
+		if _, offset := time.Now().Zone(); changed || offset != c.offset {
+			if err := c.setServerTimezone(); err != nil {
+				return driver.ErrBadConn
+			}
+		}
+	}
+	return nil
+}
+
+// Deprecated: Use PrepareContext instead
 func (c *Conn) Prepare(query string) (driver.Stmt, error) {
-	return newStmt(c, query, true), nil
+	return c.PrepareContext(context.Background(), query)
+}
+
+// release releases a prepared statement on the server, and forgets it
+func (c *Conn) release(s *Stmt) error {
+	delete(c.stmts, s)
+	return s.release()
 }
 
 func (c *Conn) Close() error {
-	// TODO: close prepared statements
-	c.mapi.Disconnect()
-	c.mapi = nil
+	// The prepared statements are released before the session ends
+	for s := range c.stmts {
+		c.release(s)
+		s.conn = nil
+	}
+	if c.mapi != nil {
+		c.mapi.Disconnect()
+		c.mapi = nil
+	}
 	return nil
 }
 
@@ -136,12 +267,17 @@ func (c *Conn) QueryContext(ctx context.Context, query string, args []driver.Nam
 	return res, err
 }
 
-func (c *Conn) PrepareContext(ctx context.Context, query string) (Stmt, error) {
+// PrepareContext prepares the statement on the server, which reports the number of
+// placeholders. The statement is released on the server when it is closed.
+func (c *Conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
 	stmt := newStmt(c, query, true)
-	return *stmt, nil
+	if err := stmt.prepare(ctx); err != nil {
+		return nil, err
+	}
+	c.stmts[stmt] = struct{}{}
+	return stmt, nil
 }
 
 func (c *Conn) CheckNamedValue(arg *driver.NamedValue) error {
-	_, err := mapi.ConvertToMonet(arg.Value)
-	return err
+	return checkNamedValue(arg, c.converters)
 }
@@ -5,6 +5,7 @@
 package monetdb
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 	"time"
@@ -36,6 +37,24 @@ func TestConnIntegration(t *testing.T) {
 		defer db.Close()
 	})
 
+	t.Run("PingContext should succeed on an open connection", func(t *testing.T) {
+		db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		if pingErr := conn.PingContext(ctx); pingErr != nil {
+			t.Error(pingErr)
+		}
+	})
+
 	t.Run("Ping should err when hostname is not correct", func(t *testing.T) {
 		db, err := sql.Open("monetdb", "monetdb:monetdb@localhost1:50000/monetdb")
 		if err != nil {
@@ -251,3 +270,98 @@ func TestConnTimezoneIntegration(t *testing.T) {
 		}
 	})
 }
+
+func TestConnResetSessionIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// All statements run on the same connection
+	db.SetMaxOpenConns(1)
+
+	var schema string
+	if err := db.QueryRow("SELECT CURRENT_SCHEMA").Scan(&schema); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Changed schema is restored", func(t *testing.T) {
+		if _, err := db.Exec("SET SCHEMA tmp"); err != nil {
+			t.Fatal(err)
+		}
+		var current string
+		if err := db.QueryRow("SELECT CURRENT_SCHEMA").Scan(&current); err != nil {
+			t.Fatal(err)
+		}
+		if current != schema {
+			t.Errorf("Unexpected schema %s, expected %s", current, schema)
+		}
+	})
+
+	t.Run("Open transaction is rolled back", func(t *testing.T) {
+		if _, err := db.Exec("START TRANSACTION"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec("START TRANSACTION"); err != nil {
+			t.Errorf("Transaction was not rolled back: %v", err)
+		}
+		if _, err := db.Exec("ROLLBACK"); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestConnTimezoneOptionIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb?timezone=Europe%2FAmsterdam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	loc, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Verify time zone of the session", func(t *testing.T) {
+		var seconds int
+		if err := db.QueryRow("select \"second\"(local_timezone())").Scan(&seconds); err != nil {
+			t.Fatal(err)
+		}
+		if _, offset := time.Now().In(loc).Zone(); seconds != offset {
+			t.Errorf("Unexpected offset %d, expected %d", seconds, offset)
+		}
+
+		conn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		err = conn.Raw(func(driverConn interface{}) error {
+			if tz := driverConn.(*Conn).Timezone(); tz.String() != "Europe/Amsterdam" {
+				t.Errorf("Unexpected time zone %v", tz)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Verify location of timestamps", func(t *testing.T) {
+		var ts time.Time
+		if err := db.QueryRow("select timestamp '2001-07-02 10:20:30'").Scan(&ts); err != nil {
+			t.Fatal(err)
+		}
+		if ts.Location().String() != "Europe/Amsterdam" || ts.Hour() != 10 {
+			t.Errorf("Unexpected timestamp %v", ts)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package monetdb
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/MonetDB/MonetDB-Go/v2/mapi"
+)
+
+type Connector struct {
+	config     Config
+	driver     *Driver
+	converters *mapi.Converters
+}
+
+// NewConnector returns a connector for the settings in the Config. The
+// connector can be passed to sql.OpenDB. Later changes to the Config do not
+// affect the connector.
+func NewConnector(config *Config) (*Connector, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	// The connector has its own driver, so the converters are not shared with other connectors
+	d := &Driver{}
+	return &Connector{
+		config:     *config,
+		driver:     d,
+		converters: &d.converters,
+	}, nil
+}
+
+// Converters returns the converters of the connections that the connector opens. They can
+// be registered while the connections are in use.
+func (c *Connector) Converters() *Converters {
+	return c.converters
+}
+
+// Connect opens a new connection. The context limits the time it takes to
+// open the connection and to log in.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return newConn(ctx, &c.config, c.converters)
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return c.driver
+}
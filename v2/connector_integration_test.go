@@ -0,0 +1,125 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package monetdb
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/MonetDB/MonetDB-Go/v2/mapi"
+)
+
+func TestConnectorIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	t.Run("Open database with a connector", func(t *testing.T) {
+		config := NewConfig()
+		config.Hostname = "localhost"
+		config.Username = "monetdb"
+		config.Password = "monetdb"
+		config.Database = "monetdb"
+		connector, err := NewConnector(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+		if pingErr := db.Ping(); pingErr != nil {
+			t.Error(pingErr)
+		}
+	})
+
+	t.Run("Open database with a connector from a parsed dsn", func(t *testing.T) {
+		config, err := ParseDSN("monetdb://localhost:50000/monetdb?user=monetdb&password=monetdb&schema=sys")
+		if err != nil {
+			t.Fatal(err)
+		}
+		connector, err := NewConnector(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db := sql.OpenDB(connector)
+		defer db.Close()
+		var schema string
+		if err := db.QueryRow("select current_schema").Scan(&schema); err != nil {
+			t.Fatal(err)
+		}
+		if schema != "sys" {
+			t.Errorf("Unexpected schema %s", schema)
+		}
+	})
+
+	t.Run("Connector should fail with an invalid config", func(t *testing.T) {
+		config := NewConfig()
+		config.TLS = true
+		config.Sock = "/tmp/.s.monetdb.50000"
+		if _, err := NewConnector(config); err == nil {
+			t.Error("Expected an error for TLS on a unix domain socket")
+		}
+	})
+}
+
+type testPoint struct {
+	X, Y int
+}
+
+func TestConnectorConvertersIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	config, err := ParseDSN("monetdb://localhost:50000/monetdb?user=monetdb&password=monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	connector, err := NewConnector(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	converters := connector.Converters()
+	converters.RegisterDecoder("decimal", func(v string) (mapi.Value, error) {
+		return "decimal " + v, nil
+	})
+	converters.RegisterEncoder(reflect.TypeOf(testPoint{}), func(v mapi.Value) (string, error) {
+		p := v.(testPoint)
+		return fmt.Sprintf("'%d,%d'", p.X, p.Y), nil
+	})
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	t.Run("Decode with a registered decoder", func(t *testing.T) {
+		var s string
+		if err := db.QueryRow("select cast(12.5 as decimal(5,2))").Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		if s != "decimal 12.50" {
+			t.Errorf("Unexpected value %s", s)
+		}
+	})
+
+	t.Run("Encode with a registered encoder", func(t *testing.T) {
+		var s string
+		if err := db.QueryRow("select ?", testPoint{1, 2}).Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		if s != "1,2" {
+			t.Errorf("Unexpected value %s", s)
+		}
+	})
+
+	t.Run("Converters are not shared between connectors", func(t *testing.T) {
+		other, err := NewConnector(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := other.Converters().Decoder("decimal"); ok {
+			t.Error("Decoder of another connector is used")
+		}
+	})
+}
@@ -7,7 +7,9 @@ package monetdb
 import (
 	"database/sql"
 	"context"
+	"errors"
 	"testing"
+	"time"
 )
   
 func TestContextDBIntegration(t *testing.T) {
@@ -623,3 +625,50 @@ func TestContextRowIntegration(t *testing.T) {
 		}
 	})
 }
+
+func TestContextCancelIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("create or replace procedure sleep(i int) external name alarm.sleep"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Cancelled query is stopped on the server", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := db.ExecContext(ctx, "CALL sys.sleep(10000)")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Query was not stopped, it took %v", elapsed)
+		}
+	})
+
+	t.Run("Connection can be used after cancellation", func(t *testing.T) {
+		var value int
+		if err := db.QueryRow("select 1").Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		if value != 1 {
+			t.Errorf("Unexpected value %d", value)
+		}
+	})
+
+	t.Run("Exec drop procedure", func(t *testing.T) {
+		if _, err := db.Exec("drop procedure sleep"); err != nil {
+			t.Error(err)
+		}
+	})
+}
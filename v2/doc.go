@@ -13,6 +13,103 @@ to the MonetDB server.
 
 If the port is not specified, then the default port 50000 will be used.
 
+To connect over a Unix domain socket, add the path of the socket as the
+sock parameter. The hostname may be left empty in that case.
+
+    [username[:password]@][hostname[:port]]/database?sock=/tmp/.s.monetdb.50000
+
+The DSN can also be given as a URL, following the MonetDB URL specification.
+Use the monetdbs scheme to encrypt the connection with TLS.
+
+    monetdb[s]://[hostname[:port]]/database[?parameters]
+
+When the hostname is empty, the Unix domain socket of the server is tried
+first, before connecting to localhost over TCP. The following parameters
+configure the session:
+
+    user        name of the user
+    password    password of the user
+    schema      initial schema of the session
+    timezone    time zone of the session, a name like Europe/Amsterdam, an
+                offset like +01:00, or minutes east of UTC
+    replysize   number of rows that are fetched at once, -1 fetches all rows
+    autocommit  true or false, autocommit is enabled by default
+    sock        path of the Unix domain socket
+    sockdir     directory of the Unix domain socket, defaults to /tmp
+    connect_timeout  maximum time to open the connection, in seconds
+    login_timeout    maximum time for the login sequence, in seconds
+
+Both DSN formats accept these parameters. Unknown parameters result in an
+error, unless the name contains an underscore. The following parameters
+configure the TLS connection:
+
+    cert        file with the certificates to verify the server certificate
+    certhash    sha256 hash of the server certificate, instead of verifying it
+    clientkey   file with the private key of the client certificate
+    clientcert  file with the client certificate, defaults to the clientkey file
+    servername  server name to verify and send with SNI, defaults to hostname
+
+Instead of a DSN, the settings can be given in a Config. Pass the
+connector from NewConnector to sql.OpenDB to use it.
+
+    config := monetdb.NewConfig()
+    config.Hostname = "localhost"
+    config.Database = "demo"
+    config.Username = "monetdb"
+    config.Password = password
+    connector, err := monetdb.NewConnector(config)
+    db := sql.OpenDB(connector)
+
+Queries take arguments for ? placeholders. Prepared statements are executed
+on the server with the arguments. For other queries the placeholders are
+replaced by the arguments on the client, a question mark in a string, a
+quoted identifier or a comment is left alone. Arguments with a name, given
+with sql.Named, are passed to the server for :name placeholders.
+
+    rows, err := db.Query("select name from t where id = ?", 5)
+
+Decimal values are returned as text, without loss of precision. Scan them
+into a Decimal to calculate with them, or into a string or a float64.
+Decimal and *big.Rat arguments are passed with all their digits.
+Hugeint values are returned as text, scan them into a HugeInt, a string, or
+an int64 when the value fits. HugeInt, *big.Int and uint64 arguments are
+accepted.
+Uuid values are returned as text, scan them into a UUID or a string.
+Json values are returned as text, scan them into a json.RawMessage. Maps,
+slices, json.Marshaler arguments and structs with json tags on their fields
+are encoded as json.
+Inet values are returned as text, scan them into an Inet, which holds a
+netip.Prefix. Url values are returned as text, scan them into a URL, which
+holds a url.URL.
+Sec_interval and day_interval values are returned as a number of nanoseconds,
+scan them into a time.Duration. Month_interval values are returned as a
+number of months, scan them into a MonthInterval. Both can also be used as
+arguments.
+Times and timestamps have microsecond precision. Timetz and timestamptz
+values are returned as a time.Time in a fixed zone with the offset that the
+server sent. Timestamps without a time zone are returned in the location
+of the timezone parameter, or in UTC when it is not given. The session gets
+the current offset of the location when the connection is made, and again
+when it is reused from the pool. A time.Time argument is passed as a
+timestamp with time zone, with microsecond precision. How times in the
+local time zone are passed can be changed with Conn.SetLocalTimePolicy.
+
+Arguments that implement driver.Valuer, like sql.NullString, are passed as
+the value they return. Pointers are passed as the value they point to, or as
+NULL when they are nil. Arguments of named types, like type UserID int64,
+are passed as a value of their underlying type.
+
+The conversions can be replaced or extended with decoders for MonetDB types,
+and encoders for Go types. They are registered on the Converters of a
+Connector, or of the Driver for connections that are opened with a DSN.
+A decoder gets the text of a value as the server sent it, an encoder returns
+a literal for the query.
+
+When the context of a query is cancelled, or its deadline expires, the
+query is stopped on the server with sys.stop, from a separate connection
+with the same settings. When the server does not stop the query in time,
+the connection is closed.
+
 Please check the project's GitHub page for more complete documentation -
 https://github.com/fajran/go-monetdb
 
@@ -5,8 +5,11 @@
 package monetdb
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+
+	"github.com/MonetDB/MonetDB-Go/v2/mapi"
 )
 
 const DriverVersion = "2.0.0"
@@ -16,9 +19,33 @@ func init() {
 }
 
 type Driver struct {
+	// The converters of the connections that are opened with a DSN
+	converters mapi.Converters
+}
+
+// Converters returns the converters of the connections that the driver opens with a DSN,
+// and of the connectors from OpenConnector. Use db.Driver() to get the driver of a sql.DB.
+func (d *Driver) Converters() *Converters {
+	return &d.converters
+}
+
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	config, err := ParseDSN(name)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(context.Background(), config, &d.converters)
 }
 
-func (*Driver) Open(name string) (driver.Conn, error) {
-	return newConn(name)
+func (d *Driver) OpenConnector(name string) (driver.Connector, error) {
+	config, err := ParseDSN(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{
+		config:     *config,
+		driver:     d,
+		converters: &d.converters,
+	}, nil
 }
 
@@ -0,0 +1,102 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// StopTimeout is the maximum time to open the side connection and stop a
+// query. The caller of StopQuery can use it as well, to wait for the stopped
+// query to return.
+const StopTimeout = 5 * time.Second
+
+// SessionId returns the id of the session on the server, which is looked up
+// when the connection is made. It is needed to stop a running query with
+// StopQuery. An error is returned when the server did not tell the id.
+func (c *MapiConn) SessionId() (int, error) {
+	if !c.haveSessionId {
+		return -1, fmt.Errorf("mapi: session id is not available")
+	}
+	return c.sessionId, nil
+}
+
+// lookupSessionId asks the server for the id of the session. A lookup that
+// fails is not remembered.
+func (c *MapiConn) lookupSessionId() error {
+	r, err := c.execute("SELECT sys.current_sessionid()")
+	if err != nil {
+		return err
+	}
+	var s ResultSet
+	if err := s.StoreResult(r); err != nil {
+		return err
+	}
+	if len(s.Rows) != 1 || len(s.Rows[0]) != 1 {
+		return fmt.Errorf("mapi: session id is not available")
+	}
+	id, ok := s.Rows[0][0].(int32)
+	if !ok {
+		return fmt.Errorf("mapi: session id is not available")
+	}
+	c.sessionId = int(id)
+	c.haveSessionId = true
+	return nil
+}
+
+// StopQuery asks the server to stop the query that is running in the session
+// with the given id, from SessionId. The query is looked up in sys.queue()
+// and stopped with sys.stop, using a separate connection with the same
+// settings. The command that runs the query returns with an error when the
+// query is stopped.
+//
+// StopQuery can be called from another goroutine while the query runs. It
+// does not use the state of the connection, so the session id must be taken
+// before the query is started.
+func (c *MapiConn) StopQuery(sessionId int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), StopTimeout)
+	defer cancel()
+
+	side, err := NewMapiFromConfig(&c.config)
+	if err != nil {
+		return err
+	}
+	if err := side.ConnectContext(ctx); err != nil {
+		return err
+	}
+	defer side.Disconnect()
+
+	stop := watchContext(ctx, side.conn)
+	defer stop()
+
+	query := fmt.Sprintf("SELECT tag FROM sys.queue() WHERE sessionid = %d AND status = 'running'", sessionId)
+	r, err := side.Execute(query)
+	if err != nil {
+		return err
+	}
+	var s ResultSet
+	if err := s.StoreResult(r); err != nil {
+		return err
+	}
+
+	for _, row := range s.Rows {
+		if _, err := side.Execute(fmt.Sprintf("CALL sys.stop(%v)", row[0])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Interrupt makes the command that is running on the connection in another
+// goroutine return with an error. The state of the protocol is unknown after
+// that, so the connection must be closed with Disconnect once the command
+// has returned.
+func (c *MapiConn) Interrupt() {
+	if c.conn != nil {
+		c.conn.SetDeadline(time.Unix(1, 0))
+	}
+}
@@ -0,0 +1,84 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCancel(t *testing.T) {
+	t.Run("Verify SessionId requires a lookup", func(t *testing.T) {
+		c := MapiConn{}
+		if _, err := c.SessionId(); err == nil {
+			t.Error("Expected an error without a session id")
+		}
+	})
+
+	t.Run("Verify a failed session id lookup is not remembered", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		go serveBlocks(server,
+			"!25005!current transaction is aborted\n",
+			"&1 0 1 1 1\n"+
+				"% .%1 # table_name\n"+
+				"% %1 # name\n"+
+				"% int # type\n"+
+				"% 2 # length\n"+
+				"% 32 0 # typesizes\n"+
+				"[ 42\t]\n")
+		c := MapiConn{State: mapi_STATE_READY, conn: client}
+
+		if err := c.lookupSessionId(); err == nil {
+			t.Error("Expected an error from the failed lookup")
+		}
+		if _, err := c.SessionId(); err == nil {
+			t.Error("A failed lookup should not be remembered")
+		}
+		if err := c.lookupSessionId(); err != nil {
+			t.Fatal(err)
+		}
+		id, err := c.SessionId()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 42 {
+			t.Errorf("Unexpected session id %d", id)
+		}
+	})
+
+	t.Run("Verify Interrupt makes a running command return", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		c := MapiConn{State: mapi_STATE_READY, conn: client}
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := c.Execute("SELECT 1")
+			done <- err
+		}()
+
+		// Read the command, but never answer it
+		buf := make([]byte, 64)
+		if _, err := server.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+		c.Interrupt()
+
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Error("Expected an error from the interrupted command")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Command did not return after the interrupt")
+		}
+		c.Disconnect()
+		if c.State != mapi_STATE_INIT {
+			t.Error("Connection should be closed")
+		}
+	})
+}
@@ -5,32 +5,194 @@
 package mapi
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
-type config struct {
+const (
+	mapi_DEFAULT_PORT       = 50000
+	mapi_DEFAULT_SOCKDIR    = "/tmp"
+	mapi_DEFAULT_SOCKPREFIX = ".s.monetdb."
+)
+
+// URL parameters that are part of the MonetDB URL specification, but that
+// have no effect in this driver.
+var ignoredParameters = map[string]bool{
+	"tableschema":        true,
+	"table":              true,
+	"binary":             true,
+	"maxprefetch":        true,
+	"client_info":        true,
+	"client_application": true,
+	"client_remark":      true,
+}
+
+// Config contains the settings of a connection to a MonetDB server.
+//
+// Use NewConfig to get a Config with the default settings, or ParseDSN to
+// create one from a DSN. Fields that are left at their zero value get the
+// default value when the connection is made, except AutoCommit.
+type Config struct {
 	Username string
 	Password string
+	// Hostname of the server. When it is empty, the Unix domain socket in
+	// SockDir is tried first, before connecting to localhost over TCP.
 	Hostname string
 	Database string
 	Port     int
+	// Path of the Unix domain socket, Hostname and Port are ignored when set
+	Sock string
+
+	SockDir    string
+	SockPrefix string
+
+	// Maximum time to open the network connection, including the TLS handshake.
+	// There is no limit when 0
+	ConnectTimeout time.Duration
+	// Maximum time for the login sequence, there is no limit when 0
+	LoginTimeout time.Duration
+	// DialContext replaces the function that opens the network connection.
+	// The network is either "tcp" or "unix". It cannot be given in a DSN.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// Initial schema of the session
+	Schema string
+	// Time zone of the session, the time zone of the server is used when nil.
+	// Timestamps without a time zone are returned in this location. The
+	// session gets the offset that the location has when the connection is
+	// made.
+	Timezone *time.Location
+	// Number of rows that are fetched at once, -1 fetches all rows
+	ReplySize  int
+	AutoCommit bool
+
+	// TLS enables encryption, the other TLS fields are only used when it is set
+	TLS bool
+	// File with the certificates to verify the server certificate
+	Cert string
+	// Hash of the server certificate in the form "sha256:hexdigits"
+	CertHash   string
+	ClientKey  string
+	ClientCert string
+	// Server name for SNI and certificate verification, defaults to Hostname
+	ServerName string
+	// TLSConfig replaces the TLS configuration that is created from the
+	// other TLS fields. It cannot be given in a DSN.
+	TLSConfig *tls.Config
+}
+
+// NewConfig returns a Config with the default settings
+func NewConfig() *Config {
+	c := defaultConfig()
+	return &c
+}
+
+// ParseDSN creates a Config from a DSN in either the URL format or the
+// [username[:password]@]hostname[:port]/database format.
+func ParseDSN(name string) (*Config, error) {
+	c, err := parseDSN(name)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate reports whether the combination of settings can be used to make
+// a connection.
+func (c *Config) Validate() error {
+	return validateConfig(*c)
+}
+
+// FormatDSN returns the DSN in the URL format. Settings that have their
+// default value are left out. The DialContext and TLSConfig fields are not
+// part of the DSN.
+func (c *Config) FormatDSN() string {
+	u := url.URL{
+		Scheme: "monetdb",
+		Path:   "/" + c.Database,
+	}
+	if c.TLS {
+		u.Scheme = "monetdbs"
+	}
+
+	host := strings.Trim(c.Hostname, "[]")
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if c.Port != 0 && c.Port != mapi_DEFAULT_PORT {
+		host = fmt.Sprintf("%s:%d", host, c.Port)
+	}
+	u.Host = host
+
+	params := url.Values{}
+	addParameter := func(key, value, defaultValue string) {
+		if value != defaultValue {
+			params.Set(key, value)
+		}
+	}
+	addParameter("user", c.Username, "")
+	addParameter("password", c.Password, "")
+	addParameter("sock", c.Sock, "")
+	if c.SockDir != "" {
+		addParameter("sockdir", c.SockDir, mapi_DEFAULT_SOCKDIR)
+	}
+	if c.SockPrefix != "" {
+		addParameter("sockprefix", c.SockPrefix, mapi_DEFAULT_SOCKPREFIX)
+	}
+	if c.ConnectTimeout != 0 {
+		params.Set("connect_timeout", formatTimeout(c.ConnectTimeout))
+	}
+	if c.LoginTimeout != 0 {
+		params.Set("login_timeout", formatTimeout(c.LoginTimeout))
+	}
+	addParameter("schema", c.Schema, "")
+	if c.Timezone != nil {
+		params.Set("timezone", formatTimezone(c.Timezone))
+	}
+	if c.ReplySize != 0 {
+		addParameter("replysize", strconv.Itoa(c.ReplySize), strconv.Itoa(MAPI_ARRAY_SIZE))
+	}
+	addParameter("autocommit", strconv.FormatBool(c.AutoCommit), "true")
+	addParameter("cert", c.Cert, "")
+	addParameter("certhash", c.CertHash, "")
+	addParameter("clientkey", c.ClientKey, "")
+	addParameter("clientcert", c.ClientCert, "")
+	addParameter("servername", c.ServerName, "")
+
+	u.RawQuery = params.Encode()
+	return u.String()
 }
 
-func parseDSN(name string) (config, error) {
+func parseDSN(name string) (Config, error) {
+	if strings.HasPrefix(name, "monetdb://") || strings.HasPrefix(name, "monetdbs://") {
+		return parseURL(name)
+	}
+
+	c := defaultConfig()
+	c.Hostname = "localhost"
+
+	name, params := splitParameters(name)
+	c, err := parseParameters(params, c)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := validateConfig(c); err != nil {
+		return Config{}, err
+	}
+
 	ipv6_re := regexp.MustCompile(`^((?P<username>[^:]+?)(:(?P<password>[^@]+?))?@)?\[(?P<hostname>(([0-9a-fA-F]{1,4}:){7,7}[0-9a-fA-F]{1,4}|([0-9a-fA-F]{1,4}:){1,7}:|([0-9a-fA-F]{1,4}:){1,6}:[0-9a-fA-F]{1,4}|([0-9a-fA-F]{1,4}:){1,5}(:[0-9a-fA-F]{1,4}){1,2}|([0-9a-fA-F]{1,4}:){1,4}(:[0-9a-fA-F]{1,4}){1,3}|([0-9a-fA-F]{1,4}:){1,3}(:[0-9a-fA-F]{1,4}){1,4}|([0-9a-fA-F]{1,4}:){1,2}(:[0-9a-fA-F]{1,4}){1,5}|[0-9a-fA-F]{1,4}:((:[0-9a-fA-F]{1,4}){1,6})|:((:[0-9a-fA-F]{1,4}){1,7}|:)|fe80:(:[0-9a-fA-F]{0,4}){0,4}%[0-9a-zA-Z]{1,}|::(ffff(:0{1,4}){0,1}:){0,1}((25[0-5]|(2[0-4]|1{0,1}[0-9]){0,1}[0-9])\.){3,3}(25[0-5]|(2[0-4]|1{0,1}[0-9]){0,1}[0-9])|([0-9a-fA-F]{1,4}:){1,4}:((25[0-5]|(2[0-4]|1{0,1}[0-9]){0,1}[0-9])\.){3,3}(25[0-5]|(2[0-4]|1{0,1}[0-9]){0,1}[0-9]))+?)\](:(?P<port>\d+?))?\/(?P<database>.+?)$`)
 
 	if ipv6_re.MatchString(name) {
 		m := ipv6_re.FindAllStringSubmatch(name, -1)[0]
 		n := ipv6_re.SubexpNames()
-		return getConfig(m, n, true), nil
-	}
-
-	c := config{
-		Hostname: "localhost",
-		Port:     50000,
+		return getConfig(m, n, true, c), nil
 	}
 
 	reversed := reverse(name)
@@ -40,23 +202,94 @@ func parseDSN(name string) (config, error) {
 	configWithHost, err := parseHost(reverse(host), c)
 
 	if err != nil {
-		return config{}, fmt.Errorf("mapi: invalid DSN")
+		return Config{}, fmt.Errorf("mapi: invalid DSN")
 	}
 
 	newConfig, err := parseCreds(reverse(creds), configWithHost)
 
 	if err != nil {
-		return config{}, fmt.Errorf("mapi: invalid DSN")
+		return Config{}, fmt.Errorf("mapi: invalid DSN")
 	}
 
 	return newConfig, nil
 }
 
-func parseCreds(creds string, c config) (config, error) {
+func defaultConfig() Config {
+	return Config{
+		Port:       mapi_DEFAULT_PORT,
+		SockDir:    mapi_DEFAULT_SOCKDIR,
+		SockPrefix: mapi_DEFAULT_SOCKPREFIX,
+		ReplySize:  MAPI_ARRAY_SIZE,
+		AutoCommit: true,
+	}
+}
+
+// parseURL parses a DSN in the URL format, as described in the MonetDB URL
+// specification
+//
+//	monetdb[s]://[hostname[:port]]/database[?parameters]
+//
+// The monetdbs scheme enables TLS. When the hostname is empty, the driver
+// first tries the Unix domain socket in the socket directory, and then falls
+// back to a TCP connection to localhost.
+func parseURL(name string) (Config, error) {
+	c := defaultConfig()
+
+	u, err := url.Parse(name)
+	if err != nil {
+		return Config{}, fmt.Errorf("mapi: invalid URL: %v", err)
+	}
+	if u.Opaque != "" || u.Fragment != "" {
+		return Config{}, fmt.Errorf("mapi: invalid URL: %s", name)
+	}
+	c.TLS = u.Scheme == "monetdbs"
+
+	if u.User != nil {
+		c.Username = u.User.Username()
+		c.Password, _ = u.User.Password()
+	}
+	c.Hostname = u.Hostname()
+	if u.Port() != "" {
+		c.Port, err = strconv.Atoi(u.Port())
+		if err != nil {
+			return Config{}, fmt.Errorf("mapi: invalid port in URL: %s", u.Port())
+		}
+	}
+	c.Database, _, _ = Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if c.TLS && c.Hostname == "" {
+		c.Hostname = "localhost"
+	}
+
+	c, err = parseParameters(u.RawQuery, c)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := validateConfig(c); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+func validateConfig(c Config) error {
+	if c.TLS && c.Sock != "" {
+		return fmt.Errorf("mapi: TLS is not supported on a Unix domain socket")
+	}
+	if c.CertHash != "" {
+		if _, err := parseCertHash(c.CertHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseCreds(creds string, c Config) (Config, error) {
+	if creds == "" {
+		return c, nil
+	}
+
 	username, password, found := Cut(creds, ":")
 
 	c.Username = username
-	c.Password = ""
 
 	if found {
 		if username == "" {
@@ -69,19 +302,23 @@ func parseCreds(creds string, c config) (config, error) {
 	return c, nil
 }
 
-func parseHost(host string, c config) (config, error) {
+func parseHost(host string, c Config) (Config, error) {
 	host, dbName, found := Cut(host, "/")
 
 	if !found {
 		return c, fmt.Errorf("mapi: invalid DSN")
 	}
 
-	if host == "" {
+	if host == "" && c.Sock == "" {
 		return c, fmt.Errorf("mapi: invalid DSN")
 	}
 
 	c.Database = dbName
 
+	if host == "" {
+		return c, nil
+	}
+
 	hostname, port, found := Cut(host, ":")
 
 	if !found {
@@ -101,15 +338,11 @@ func parseHost(host string, c config) (config, error) {
 	return c, nil
 }
 
-func getConfig(m []string, n []string, ipv6 bool) config {
-	c := config{
-		Hostname: "localhost",
-		Port:     50000,
-	}
+func getConfig(m []string, n []string, ipv6 bool, c Config) Config {
 	for i, v := range m {
-		if n[i] == "username" {
+		if n[i] == "username" && v != "" {
 			c.Username = v
-		} else if n[i] == "password" {
+		} else if n[i] == "password" && v != "" {
 			c.Password = v
 		} else if n[i] == "hostname" {
 			if ipv6 {
@@ -128,6 +361,183 @@ func getConfig(m []string, n []string, ipv6 bool) config {
 	return c
 }
 
+// splitParameters separates the optional parameters from the DSN. The
+// parameters follow the database name after a question mark, for example
+// "monetdb:monetdb@localhost/demo?sock=/tmp/.s.monetdb.50000".
+func splitParameters(name string) (string, string) {
+	start := strings.LastIndex(name, "@") + 1
+	if i := strings.Index(name[start:], "?"); i >= 0 {
+		return name[:start+i], name[start+i+1:]
+	}
+	return name, ""
+}
+
+func parseParameters(params string, c Config) (Config, error) {
+	if params == "" {
+		return c, nil
+	}
+
+	values, err := url.ParseQuery(params)
+	if err != nil {
+		return c, fmt.Errorf("mapi: invalid DSN parameters: %v", err)
+	}
+
+	for key, value := range values {
+		c, err = setParameter(c, key, value[len(value)-1])
+		if err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func setParameter(c Config, key string, value string) (Config, error) {
+	switch key {
+	case "sock":
+		c.Sock = value
+	case "tls":
+		enabled, err := parseBool(value)
+		if err != nil {
+			return c, fmt.Errorf("mapi: invalid value for DSN parameter %s: %s", key, value)
+		}
+		c.TLS = enabled
+	case "cert":
+		c.Cert = value
+	case "certhash":
+		c.CertHash = value
+	case "clientkey":
+		c.ClientKey = value
+	case "clientcert":
+		c.ClientCert = value
+	case "servername":
+		c.ServerName = value
+	case "sockdir":
+		c.SockDir = value
+	case "sockprefix":
+		c.SockPrefix = value
+	case "connect_timeout":
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return c, fmt.Errorf("mapi: invalid value for DSN parameter %s: %s", key, value)
+		}
+		c.ConnectTimeout = timeout
+	case "login_timeout":
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return c, fmt.Errorf("mapi: invalid value for DSN parameter %s: %s", key, value)
+		}
+		c.LoginTimeout = timeout
+	case "user":
+		c.Username = value
+	case "password":
+		c.Password = value
+	case "language":
+		if value != "sql" {
+			return c, fmt.Errorf("mapi: unsupported language: %s", value)
+		}
+	case "schema":
+		c.Schema = value
+	case "timezone":
+		loc, err := parseTimezone(value)
+		if err != nil {
+			return c, fmt.Errorf("mapi: invalid value for DSN parameter %s: %s", key, value)
+		}
+		c.Timezone = loc
+	case "replysize", "fetchsize":
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			return c, fmt.Errorf("mapi: invalid value for DSN parameter %s: %s", key, value)
+		}
+		c.ReplySize = size
+	case "autocommit":
+		enabled, err := parseBool(value)
+		if err != nil {
+			return c, fmt.Errorf("mapi: invalid value for DSN parameter %s: %s", key, value)
+		}
+		c.AutoCommit = enabled
+	default:
+		// Unknown parameters with an underscore in the name are reserved
+		// for other clients, and they are ignored.
+		if ignoredParameters[key] || strings.Contains(key, "_") {
+			return c, nil
+		}
+		return c, fmt.Errorf("mapi: unknown DSN parameter: %s", key)
+	}
+	return c, nil
+}
+
+// parseTimeout accepts a number of seconds, or a duration like "1m30s"
+func parseTimeout(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("mapi: invalid timeout: %s", value)
+	}
+	return timeout, nil
+}
+
+// formatTimeout returns the timeout as a number of seconds when possible
+func formatTimeout(timeout time.Duration) string {
+	if timeout%time.Second == 0 {
+		return strconv.FormatInt(int64(timeout/time.Second), 10)
+	}
+	return timeout.String()
+}
+
+// parseTimezone accepts a number of minutes east of UTC, an offset like
+// "+01:00", or the name of a location like "Europe/Amsterdam"
+func parseTimezone(value string) (*time.Location, error) {
+	// In a query string an unescaped + is decoded as a space
+	if strings.HasPrefix(value, " ") {
+		value = "+" + value[1:]
+	}
+	if minutes, err := strconv.Atoi(value); err == nil {
+		return time.FixedZone("", minutes*60), nil
+	}
+	if len(value) > 0 && (value[0] == '+' || value[0] == '-') {
+		t, err := time.Parse("-07:00", value)
+		if err != nil {
+			return nil, fmt.Errorf("mapi: invalid time zone: %s", value)
+		}
+		_, offset := t.Zone()
+		return time.FixedZone("", offset), nil
+	}
+	// The empty name and "Local" are not accepted, they do not name a time zone
+	if value == "" || value == "Local" {
+		return nil, fmt.Errorf("mapi: invalid time zone: %s", value)
+	}
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("mapi: invalid time zone: %s", value)
+	}
+	return loc, nil
+}
+
+// formatTimezone returns the name of a location, or the number of minutes
+// east of UTC for a zone that cannot be loaded by its name
+func formatTimezone(loc *time.Location) string {
+	if name := loc.String(); name != "" && name != "Local" {
+		if _, err := time.LoadLocation(name); err == nil {
+			return name
+		}
+	}
+	_, offset := time.Now().In(loc).Zone()
+	return strconv.Itoa(offset / 60)
+}
+
+// parseBool accepts the boolean values of the MonetDB URL specification
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("mapi: invalid boolean value: %s", value)
+}
+
 func reverse(in string) string {
 	var sb strings.Builder
 	runes := []rune(in)
@@ -5,8 +5,10 @@
 package mapi
 
 import (
+	"net/url"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestParseDSN(t *testing.T) {
@@ -100,3 +102,269 @@ func TestParseIpv6DSN(t *testing.T) {
 	}
 
 }
+
+func TestParseSocketDSN(t *testing.T) {
+	tcs := [][]string{
+		{"me:secret@localhost/testdb?sock=/tmp/.s.monetdb.50000", "me", "secret", "/tmp/.s.monetdb.50000", "testdb"},
+		{"me:secret@/testdb?sock=/tmp/.s.monetdb.50000", "me", "secret", "/tmp/.s.monetdb.50000", "testdb"},
+		{"me:pass?word@/testdb?sock=/tmp/.s.monetdb.50000", "me", "pass?word", "/tmp/.s.monetdb.50000", "testdb"},
+		{"me:secret@[::1]:1234/testdb?sock=/tmp/.s.monetdb.1234", "me", "secret", "/tmp/.s.monetdb.1234", "testdb"},
+		{"me:secret@localhost/testdb", "me", "secret", "", "testdb"},
+		{"me:secret@/testdb?sock="},
+		{"me:secret@localhost/testdb?unknown=1"},
+	}
+
+	for _, tc := range tcs {
+		n := tc[0]
+		ok := len(tc) > 1
+		c, err := parseDSN(n)
+
+		if ok && err != nil {
+			t.Errorf("Error parsing DSN: %s -> %v", n, err)
+		} else if !ok && err == nil {
+			t.Errorf("Error parsing invalid DSN: %s", n)
+		}
+
+		if !ok || err != nil {
+			continue
+		}
+
+		if c.Username != tc[1] {
+			t.Errorf("Invalid username: %s, expected: %s", c.Username, tc[1])
+		}
+		if c.Password != tc[2] {
+			t.Errorf("Invalid password: %s, expected: %s", c.Password, tc[2])
+		}
+		if c.Sock != tc[3] {
+			t.Errorf("Invalid socket: %s, expected: %s", c.Sock, tc[3])
+		}
+		if c.Database != tc[4] {
+			t.Errorf("Invalid database: %s, expected: %s", c.Database, tc[4])
+		}
+	}
+}
+
+func TestParseTLSDSN(t *testing.T) {
+	t.Run("Verify monetdbs scheme enables tls", func(t *testing.T) {
+		c, err := parseDSN("monetdbs://db.example.com:50001/testdb?cert=/etc/ssl/ca.pem&servername=monetdb.example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !c.TLS {
+			t.Error("TLS is not enabled")
+		}
+		if c.Hostname != "db.example.com" || c.Port != 50001 || c.Database != "testdb" {
+			t.Errorf("Unexpected address: %s %d %s", c.Hostname, c.Port, c.Database)
+		}
+		if c.Cert != "/etc/ssl/ca.pem" || c.ServerName != "monetdb.example.com" {
+			t.Errorf("Unexpected tls parameters: %s %s", c.Cert, c.ServerName)
+		}
+	})
+
+	t.Run("Verify monetdb scheme does not enable tls", func(t *testing.T) {
+		c, err := parseDSN("monetdb://localhost/testdb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.TLS {
+			t.Error("TLS is enabled")
+		}
+		if c.Port != 50000 {
+			t.Errorf("Unexpected port: %d", c.Port)
+		}
+	})
+
+	t.Run("Verify client certificate parameters", func(t *testing.T) {
+		c, err := parseDSN("me:secret@localhost/testdb?tls=true&clientkey=/key.pem&clientcert=/cert.pem&certhash=sha256:0a:1b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !c.TLS || c.ClientKey != "/key.pem" || c.ClientCert != "/cert.pem" || c.CertHash != "sha256:0a:1b" {
+			t.Errorf("Unexpected tls parameters: %v", c)
+		}
+	})
+
+	t.Run("Verify invalid tls configurations", func(t *testing.T) {
+		tcs := []string{
+			"monetdbs://localhost/testdb?sock=/tmp/.s.monetdb.50000",
+			"monetdbs://localhost/testdb?certhash=md5:0123",
+			"monetdbs://localhost/testdb?certhash=sha256:xyz",
+			"monetdb://localhost/testdb?tls=maybe",
+			"monetdb://localhost:port/testdb",
+		}
+		for _, tc := range tcs {
+			if _, err := parseDSN(tc); err == nil {
+				t.Errorf("Error parsing invalid DSN: %s", tc)
+			}
+		}
+	})
+}
+
+func TestParseURL(t *testing.T) {
+	t.Run("Verify url with all session parameters", func(t *testing.T) {
+		c, err := parseDSN("monetdb://db.example.com:50001/testdb?user=me&password=s%26cret&schema=sys&timezone=-90&replysize=500&autocommit=off")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Hostname != "db.example.com" || c.Port != 50001 || c.Database != "testdb" {
+			t.Errorf("Unexpected address: %s %d %s", c.Hostname, c.Port, c.Database)
+		}
+		if c.Username != "me" || c.Password != "s&cret" {
+			t.Errorf("Unexpected credentials: %s %s", c.Username, c.Password)
+		}
+		if c.Schema != "sys" || c.ReplySize != 500 || c.AutoCommit {
+			t.Errorf("Unexpected session parameters: %s %d %t", c.Schema, c.ReplySize, c.AutoCommit)
+		}
+		if _, offset := time.Now().In(c.Timezone).Zone(); offset != -90*60 {
+			t.Errorf("Unexpected timezone offset: %d", offset)
+		}
+	})
+
+	t.Run("Verify time zones", func(t *testing.T) {
+		tcs := map[string]int{
+			"60":     3600,
+			"+05:30": 5*3600 + 1800,
+			"-01:30": -90 * 60,
+			"UTC":    0,
+		}
+		for value, expected := range tcs {
+			c, err := parseDSN("monetdb:///testdb?timezone=" + url.QueryEscape(value))
+			if err != nil {
+				t.Errorf("Error parsing time zone %s: %v", value, err)
+			} else if _, offset := time.Now().In(c.Timezone).Zone(); offset != expected {
+				t.Errorf("Unexpected offset of %s: %d, expected: %d", value, offset, expected)
+			}
+		}
+
+		// The + of an offset does not have to be escaped
+		for _, dsn := range []string{"monetdb:///testdb?timezone=+01:00", "me@localhost/testdb?timezone=+01:00"} {
+			c, err := parseDSN(dsn)
+			if err != nil {
+				t.Errorf("Error parsing %s: %v", dsn, err)
+			} else if _, offset := time.Now().In(c.Timezone).Zone(); offset != 3600 {
+				t.Errorf("Unexpected offset of %s: %d", dsn, offset)
+			}
+		}
+
+		c, err := parseDSN("monetdb:///testdb?timezone=Europe%2FAmsterdam")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Timezone.String() != "Europe/Amsterdam" {
+			t.Errorf("Unexpected time zone: %s", c.Timezone)
+		}
+	})
+
+	t.Run("Verify url defaults", func(t *testing.T) {
+		c, err := parseDSN("monetdb:///testdb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Hostname != "" || c.Port != 50000 || c.Database != "testdb" {
+			t.Errorf("Unexpected address: %s %d %s", c.Hostname, c.Port, c.Database)
+		}
+		if c.SockDir != "/tmp" || c.SockPrefix != ".s.monetdb." {
+			t.Errorf("Unexpected socket location: %s %s", c.SockDir, c.SockPrefix)
+		}
+		if c.ReplySize != MAPI_ARRAY_SIZE || !c.AutoCommit || c.Timezone != nil || c.Schema != "" {
+			t.Errorf("Unexpected session parameters: %d %t %v %s", c.ReplySize, c.AutoCommit, c.Timezone, c.Schema)
+		}
+	})
+
+	t.Run("Verify url with ipv6 address and table path", func(t *testing.T) {
+		c, err := parseDSN("monetdb://[::1]:1234/testdb/sys/tables?fetchsize=10&client_info=off")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Hostname != "::1" || c.Port != 1234 || c.Database != "testdb" || c.ReplySize != 10 {
+			t.Errorf("Unexpected parameters: %s %d %s %d", c.Hostname, c.Port, c.Database, c.ReplySize)
+		}
+	})
+
+	t.Run("Verify url with timeouts", func(t *testing.T) {
+		c, err := parseDSN("monetdb://localhost/testdb?connect_timeout=10&login_timeout=1m30s")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.ConnectTimeout != 10*time.Second || c.LoginTimeout != 90*time.Second {
+			t.Errorf("Unexpected timeouts: %v %v", c.ConnectTimeout, c.LoginTimeout)
+		}
+		if dsn := c.FormatDSN(); dsn != "monetdb://localhost/testdb?connect_timeout=10&login_timeout=90" {
+			t.Errorf("Unexpected DSN: %s", dsn)
+		}
+	})
+
+	t.Run("Verify legacy dsn with session parameters", func(t *testing.T) {
+		c, err := parseDSN("me:secret@localhost:1234/testdb?schema=tenant&autocommit=false")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Username != "me" || c.Password != "secret" || c.Schema != "tenant" || c.AutoCommit {
+			t.Errorf("Unexpected parameters: %s %s %s %t", c.Username, c.Password, c.Schema, c.AutoCommit)
+		}
+	})
+
+	t.Run("Verify invalid urls", func(t *testing.T) {
+		tcs := []string{
+			"monetdb://localhost/testdb?unknown=1",
+			"monetdb://localhost/testdb?replysize=many",
+			"monetdb://localhost/testdb?timezone=Europe",
+			"monetdb://localhost/testdb?timezone=%2B1:00",
+			"monetdb://localhost/testdb?timezone=Local",
+			"monetdb://localhost/testdb?autocommit=maybe",
+			"monetdb://localhost/testdb?connect_timeout=-1",
+			"monetdb://localhost/testdb?login_timeout=soon",
+			"monetdb://localhost/testdb?language=mal",
+			"monetdb://localhost/testdb?user=%zz",
+			"monetdb://localhost/testdb#fragment",
+		}
+		for _, tc := range tcs {
+			if _, err := parseDSN(tc); err == nil {
+				t.Errorf("Error parsing invalid DSN: %s", tc)
+			}
+		}
+	})
+}
+
+func TestFormatDSN(t *testing.T) {
+	t.Run("Verify round trip of the url format", func(t *testing.T) {
+		tcs := []string{
+			"monetdb:///testdb",
+			"monetdb://localhost/testdb",
+			"monetdb://[::1]:1234/testdb",
+			"monetdb://db.example.com/testdb?autocommit=false&password=s%26cret&replysize=500&schema=sys&timezone=60&user=me",
+			"monetdb:///testdb?sock=%2Ftmp%2F.s.monetdb.50000",
+			"monetdb:///testdb?timezone=Europe%2FAmsterdam",
+			"monetdbs://db.example.com/testdb?cert=%2Fetc%2Fca.pem&clientkey=%2Fkey.pem&servername=monetdb",
+		}
+		for _, tc := range tcs {
+			c, err := ParseDSN(tc)
+			if err != nil {
+				t.Errorf("Error parsing DSN: %s -> %v", tc, err)
+				continue
+			}
+			if dsn := c.FormatDSN(); dsn != tc {
+				t.Errorf("Unexpected DSN: %s, expected: %s", dsn, tc)
+			}
+		}
+	})
+
+	t.Run("Verify legacy dsn is formatted as url", func(t *testing.T) {
+		c, err := ParseDSN("me:secret@localhost:1234/testdb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "monetdb://localhost:1234/testdb?password=secret&user=me"
+		if dsn := c.FormatDSN(); dsn != expected {
+			t.Errorf("Unexpected DSN: %s, expected: %s", dsn, expected)
+		}
+	})
+
+	t.Run("Verify config without defaults", func(t *testing.T) {
+		c := Config{Hostname: "localhost", Database: "testdb", AutoCommit: true}
+		expected := "monetdb://localhost/testdb"
+		if dsn := c.FormatDSN(); dsn != expected {
+			t.Errorf("Unexpected DSN: %s, expected: %s", dsn, expected)
+		}
+	})
+}
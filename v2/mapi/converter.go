@@ -5,7 +5,12 @@
 package mapi
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"math/big"
+	"net/netip"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
@@ -22,7 +27,7 @@ const (
 	MDB_SMALLINT  = "smallint" // 16 bit integer
 	MDB_INT       = "int"      // 32 bit integer
 	MDB_BIGINT    = "bigint"   // 64 bit integer
-	MDB_HUGEINT   = "hugeint"  // 64 bit integer
+	MDB_HUGEINT   = "hugeint"  // 128 bit integer
 	MDB_SERIAL    = "serial"   // special 64 bit integer sequence generator
 	MDB_REAL      = "real"     // 32 bit floating point
 	MDB_DOUBLE    = "double"   // 64 bit floating point
@@ -35,6 +40,7 @@ const (
 
 	MDB_MONTH_INTERVAL = "month_interval"
 	MDB_SEC_INTERVAL   = "sec_interval"
+	MDB_DAY_INTERVAL   = "day_interval"
 	MDB_WRD            = "wrd"
 	MDB_TINYINT        = "tinyint"
 
@@ -44,6 +50,13 @@ const (
 	MDB_LONGINT     = "longint"
 	MDB_FLOAT       = "float"
 	MDB_TIMESTAMPTZ = "timestamptz"
+	MDB_TIMETZ      = "timetz"
+
+	// Types of extensions
+	MDB_UUID = "uuid"
+	MDB_JSON = "json"
+	MDB_INET = "inet"
+	MDB_URL  = "url"
 
 	// full names and aliases, spaces are replaced with underscores
 	//lint:ignore U1000 prepare to enable staticchecks
@@ -60,22 +73,20 @@ const (
 	mdb_DOUBLE_PRECISION = MDB_DOUBLE
 )
 
-var timeFormats = []string{
-	"2006-01-02",
-	"2006-01-02 15:04:05",
-	"2006-01-02 15:04:05 -0700",
-	"2006-01-02 15:04:05 -0700 MST",
-	"Mon Jan 2 15:04:05 -0700 MST 2006",
-	"2006-01-02 15:04:05.999999+00:00",
-	"2006-01-02 15:04:05.999999-07:00",
-	"15:04:05",
-}
+// The layouts of the temporal types. When parsing, a fraction of a second is
+// accepted after the seconds, although the layouts do not have one.
+const (
+	dateLayout      = "2006-01-02"
+	timeLayout      = "15:04:05"
+	timestampLayout = "2006-01-02 15:04:05"
+)
 
 type toGoConverter func(string) (Value, error)
 type toMonetConverter func(Value) (string, error)
 
+// strip removes the quotes around a value, when it has them, and unescapes it
 func strip(v string) (Value, error) {
-	return unquote(strings.TrimSpace(v[1 : len(v)-1]))
+	return unquote(strings.TrimSpace(unquoted(v)))
 }
 
 // from strconv.contains
@@ -99,9 +110,15 @@ func unquote(s string) (string, error) {
 	var runeTmp [utf8.UTFMax]byte
 	buf := make([]byte, 0, 3*len(s)/2) // Try to avoid more allocations.
 	for len(s) > 0 {
-		c, multibyte, ss, err := strconv.UnquoteChar(s, '\'')
+		// Both quotes can be escaped, the server escapes double quotes
+		if len(s) > 1 && s[0] == '\\' && (s[1] == '"' || s[1] == '\'') {
+			buf = append(buf, s[1])
+			s = s[2:]
+			continue
+		}
+		// The server sends the value in double quotes, an apostrophe is not escaped
+		c, multibyte, ss, err := strconv.UnquoteChar(s, '"')
 		if err != nil {
-			fmt.Printf("E: %v\n -> %s\n", err, s)
 			return "", err
 		}
 		s = ss
@@ -119,6 +136,10 @@ func toByteArray(v string) (Value, error) {
 	return []byte(v[1 : len(v)-1]), nil
 }
 
+func toDecimal(v string) (Value, error) {
+	return ParseDecimal(v)
+}
+
 func toDouble(v string) (Value, error) {
 	return strconv.ParseFloat(v, 64)
 }
@@ -170,14 +191,97 @@ func toInt64(v string) (Value, error) {
 	return r, err
 }
 
-func parseTime(v string) (t time.Time, err error) {
-	for _, f := range timeFormats {
-		t, err = time.Parse(f, v)
-		if err == nil {
-			return
+func toBigInt(v string) (Value, error) {
+	i, ok := new(big.Int).SetString(v, 10)
+	if !ok {
+		return nil, fmt.Errorf("mapi: invalid hugeint: %q", v)
+	}
+	return i, nil
+}
+
+// The server might quote the values of extension types
+func unquoted(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+func toUUID(v string) (Value, error) {
+	return ParseUUID(unquoted(v))
+}
+
+func toJSON(v string) (Value, error) {
+	if len(v) >= 2 && v[0] == '"' {
+		s, err := strip(v)
+		if err != nil {
+			return nil, err
 		}
+		return json.RawMessage(s.(string)), nil
+	}
+	return json.RawMessage(v), nil
+}
+
+func toDuration(v string) (Value, error) {
+	return parseDuration(v)
+}
+
+func toMonthInterval(v string) (Value, error) {
+	i, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("mapi: invalid month interval: %q", v)
+	}
+	return MonthInterval(i), nil
+}
+
+func toInet(v string) (Value, error) {
+	return ParseInet(unquoted(v))
+}
+
+func toURL(v string) (Value, error) {
+	s := unquoted(v)
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("mapi: invalid url: %q", s)
+	}
+	return u, nil
+}
+
+// splitOffset splits the offset from the value of a type with a time zone,
+// like "10:20:30.5+01:00", and returns the offset as a fixed zone
+func splitOffset(v string) (string, *time.Location, error) {
+	// The offset follows the time, a minus sign of the date is not an offset
+	start := strings.IndexByte(v, ' ') + 1
+	i := strings.IndexAny(v[start:], "+-")
+	if i < 0 {
+		return "", nil, fmt.Errorf("mapi: missing time zone offset: %q", v)
+	}
+	i += start
+	offset := strings.ReplaceAll(v[i+1:], ":", "")
+	if len(offset) == 2 {
+		offset += "00"
+	}
+	if len(offset) != 4 {
+		return "", nil, fmt.Errorf("mapi: invalid time zone offset: %q", v)
 	}
-	return
+	hours, errHours := strconv.ParseUint(offset[:2], 10, 8)
+	minutes, errMinutes := strconv.ParseUint(offset[2:], 10, 8)
+	if errHours != nil || errMinutes != nil {
+		return "", nil, fmt.Errorf("mapi: invalid time zone offset: %q", v)
+	}
+	seconds := int(hours*3600 + minutes*60)
+	if v[i] == '-' {
+		seconds = -seconds
+	}
+	return strings.TrimSpace(v[:i]), time.FixedZone("", seconds), nil
+}
+
+func parseTemporal(layout, v string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(layout, v, loc)
+	if err != nil {
+		return t, fmt.Errorf("mapi: invalid temporal value: %q", v)
+	}
+	return t, nil
 }
 
 func toNil(v string) (Value, error) {
@@ -189,27 +293,45 @@ func toBool(v string) (Value, error) {
 }
 
 func toDate(v string) (Value, error) {
-	t, err := parseTime(v)
+	t, err := parseTemporal(dateLayout, v, time.UTC)
 	if err != nil {
 		return nil, err
 	}
-	year, month, day := t.Date()
-	return Date{year, month, day}, nil
+	return GetDate(t), nil
 }
 
 func toTime(v string) (Value, error) {
-	t, err := parseTime(v)
+	t, err := parseTemporal(timeLayout, v, time.UTC)
 	if err != nil {
 		return nil, err
 	}
-	hour, min, sec := t.Clock()
-	return Time{hour, min, sec}, nil
+	return GetTime(t), nil
 }
+
+// toTimeTz returns the time with its offset on January 1, 1970, like
+// Time.Time does for a time without a time zone
+func toTimeTz(v string) (Value, error) {
+	clock, loc, err := splitOffset(v)
+	if err != nil {
+		return nil, err
+	}
+	t, err := parseTemporal(timeLayout, clock, loc)
+	if err != nil {
+		return nil, err
+	}
+	return time.Date(1970, time.January, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc), nil
+}
+
 func toTimestamp(v string) (Value, error) {
-	return parseTime(v)
+	return parseTemporal(timestampLayout, v, time.UTC)
 }
+
 func toTimestampTz(v string) (Value, error) {
-	return parseTime(v)
+	timestamp, loc, err := splitOffset(v)
+	if err != nil {
+		return nil, err
+	}
+	return parseTemporal(timestampLayout, timestamp, loc)
 }
 
 var toGoMappers = map[string]toGoConverter{
@@ -217,13 +339,13 @@ var toGoMappers = map[string]toGoConverter{
 	MDB_VARCHAR:        strip,
 	MDB_CLOB:           strip,
 	MDB_BLOB:           toByteArray,
-	MDB_DECIMAL:        toDouble,
+	MDB_DECIMAL:        toDecimal,
 	MDB_NULL:           toNil,
 	MDB_SMALLINT:       toInt16,
 	MDB_INT:            toInt32,
 	MDB_WRD:            toInt32,
 	MDB_BIGINT:         toInt64,
-	MDB_HUGEINT:        toInt64,
+	MDB_HUGEINT:        toBigInt,
 	MDB_SERIAL:         toInt64,
 	MDB_REAL:           toReal,
 	MDB_DOUBLE:         toDouble,
@@ -233,13 +355,19 @@ var toGoMappers = map[string]toGoConverter{
 	MDB_TIME:           toTime,
 	MDB_TIMESTAMP:      toTimestamp,
 	MDB_TIMESTAMPTZ:    toTimestampTz,
+	MDB_TIMETZ:         toTimeTz,
 	MDB_INTERVAL:       strip,
-	MDB_MONTH_INTERVAL: strip,
-	MDB_SEC_INTERVAL:   strip,
+	MDB_MONTH_INTERVAL: toMonthInterval,
+	MDB_SEC_INTERVAL:   toDuration,
+	MDB_DAY_INTERVAL:   toDuration,
 	MDB_TINYINT:        toInt8,
 	MDB_SHORTINT:       toInt16,
 	MDB_MEDIUMINT:      toInt32,
 	MDB_LONGINT:        toInt64,
+	MDB_UUID:           toUUID,
+	MDB_JSON:           toJSON,
+	MDB_INET:           toInet,
+	MDB_URL:            toURL,
 }
 
 func toString(v Value) (string, error) {
@@ -253,6 +381,82 @@ func toQuotedString(v Value) (string, error) {
 	return fmt.Sprintf("'%v'", s), nil
 }
 
+func toBigIntString(v Value) (string, error) {
+	switch val := v.(type) {
+	case *big.Int:
+		if val == nil {
+			return "NULL", nil
+		}
+		return val.String(), nil
+	case HugeInt:
+		return val.String(), nil
+	default:
+		return "", fmt.Errorf("mapi: unsupported type")
+	}
+}
+
+func toUUIDString(v Value) (string, error) {
+	switch val := v.(type) {
+	case UUID:
+		return toQuotedString(val.String())
+	default:
+		return "", fmt.Errorf("mapi: unsupported type")
+	}
+}
+
+func toJSONString(v Value) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("mapi: cannot encode json: %w", err)
+	}
+	return toQuotedString(string(b))
+}
+
+func toInetString(v Value) (string, error) {
+	var i Inet
+	switch val := v.(type) {
+	case Inet:
+		i = val
+	case netip.Prefix:
+		i = Inet{val}
+	case netip.Addr:
+		i = Inet{netip.PrefixFrom(val, val.BitLen())}
+	default:
+		return "", fmt.Errorf("mapi: unsupported type")
+	}
+	if !i.Prefix.IsValid() {
+		return "", fmt.Errorf("mapi: invalid inet: %v", v)
+	}
+	return toQuotedString(i.String())
+}
+
+func toURLString(v Value) (string, error) {
+	switch val := v.(type) {
+	case URL:
+		return toQuotedString(val.URL.String())
+	case url.URL:
+		return toQuotedString(val.String())
+	case *url.URL:
+		if val == nil {
+			return "NULL", nil
+		}
+		return toQuotedString(val.String())
+	default:
+		return "", fmt.Errorf("mapi: unsupported type")
+	}
+}
+
+func toIntervalString(v Value) (string, error) {
+	switch val := v.(type) {
+	case time.Duration:
+		return fmt.Sprintf("INTERVAL '%s' SECOND", formatDuration(val)), nil
+	case MonthInterval:
+		return fmt.Sprintf("INTERVAL '%d' MONTH", int32(val)), nil
+	default:
+		return "", fmt.Errorf("mapi: unsupported type")
+	}
+}
+
 func toNull(v Value) (string, error) {
 	return "NULL", nil
 }
@@ -266,10 +470,41 @@ func toByteString(v Value) (string, error) {
 	}
 }
 
+func toDecimalString(v Value) (string, error) {
+	switch val := v.(type) {
+	case Decimal:
+		return val.String(), nil
+	case *big.Rat:
+		if val == nil {
+			return "NULL", nil
+		}
+		d, err := exactDecimal(val)
+		if err != nil {
+			return "", err
+		}
+		return d.String(), nil
+	default:
+		return "", fmt.Errorf("mapi: unsupported type")
+	}
+}
+
+// toTimestampString writes a timestamp with its offset, rounded to the
+// microsecond precision of the server. Rounding also drops the reading of the
+// monotonic clock.
+func toTimestampString(v Value) (string, error) {
+	switch val := v.(type) {
+	case time.Time:
+		t := val.Round(time.Microsecond)
+		return fmt.Sprintf("TIMESTAMP WITH TIME ZONE '%s'", t.Format("2006-01-02 15:04:05.999999-07:00")), nil
+	default:
+		return "", fmt.Errorf("mapi: unsupported type")
+	}
+}
+
 func toDateTimeString(v Value) (string, error) {
 	switch val := v.(type) {
 	case Time:
-		return toQuotedString(fmt.Sprintf("%02d:%02d:%02d", val.Hour, val.Min, val.Sec))
+		return toQuotedString(val.String())
 	case Date:
 		return toQuotedString(fmt.Sprintf("%04d-%02d-%02d", val.Year, val.Month, val.Day))
 	default:
@@ -283,6 +518,11 @@ var toMonetMappers = map[string]toMonetConverter{
 	"int16":     toString,
 	"int32":     toString,
 	"int64":     toString,
+	"uint":      toString,
+	"uint8":     toString,
+	"uint16":    toString,
+	"uint32":    toString,
+	"uint64":    toString,
 	"float":     toString,
 	"float32":   toString,
 	"float64":   toString,
@@ -291,9 +531,27 @@ var toMonetMappers = map[string]toMonetConverter{
 	"nil":       toNull,
 	"null":      toNull,
 	"[]uint8":   toByteString,
-	"time.Time": toQuotedString,
+	"time.Time": toTimestampString,
 	"mapi.Time": toDateTimeString,
 	"mapi.Date": toDateTimeString,
+
+	"mapi.Decimal": toDecimalString,
+	"*big.Rat":     toDecimalString,
+	"*big.Int":     toBigIntString,
+	"mapi.HugeInt": toBigIntString,
+	"mapi.UUID":    toUUIDString,
+
+	"json.RawMessage": toJSONString,
+
+	"time.Duration":      toIntervalString,
+	"mapi.MonthInterval": toIntervalString,
+
+	"mapi.Inet":    toInetString,
+	"netip.Prefix": toInetString,
+	"netip.Addr":   toInetString,
+	"mapi.URL":     toURLString,
+	"url.URL":      toURLString,
+	"*url.URL":     toURLString,
 }
 
 func convertToGo(value, dataType string) (Value, error) {
@@ -308,15 +566,119 @@ func convertToGo(value, dataType string) (Value, error) {
 	return nil, fmt.Errorf("mapi: type not supported: %s", dataType)
 }
 
+// ConvertToMonet converts a value to a literal for a query. Values of types
+// without a converter are first normalized with NormalizeValue.
 func ConvertToMonet(value Value) (string, error) {
-	t := reflect.TypeOf(value)
-	n := "nil"
-	if t != nil {
-		n = t.String()
+	v, err := NormalizeValue(value)
+	if err != nil {
+		return "", err
 	}
+	if mapper, ok := toMonetMappers[typeName(v)]; ok {
+		return mapper(v)
+	}
+	if isJSONValue(v) {
+		return toJSONString(v)
+	}
+	return "", fmt.Errorf("mapi: type not supported: %v", reflect.TypeOf(v))
+}
 
-	if mapper, ok := toMonetMappers[n]; ok {
-		return mapper(value)
+func typeName(v Value) string {
+	if t := reflect.TypeOf(v); t != nil {
+		return t.String()
+	}
+	return "nil"
+}
+
+// The number of times a value can be replaced by the value of a
+// driver.Valuer or of a pointer, before it is rejected
+const maxValueDepth = 16
+
+// NormalizeValue returns the value that is passed to the server, for a value
+// of a type without a converter. That is the result of a driver.Valuer, the
+// value a pointer points to, or for a named type like "type ID int64" the
+// value of its basic type. A nil pointer is NULL. Values of types with a
+// converter, like Decimal, are returned unchanged.
+func NormalizeValue(v Value) (Value, error) {
+	for i := 0; i < maxValueDepth; i++ {
+		if _, ok := toMonetMappers[typeName(v)]; ok || v == nil {
+			return v, nil
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, nil
+		}
+		// A pointer to a type with a converter, like *Decimal, is not used as a
+		// driver.Valuer
+		if rv.Kind() == reflect.Ptr {
+			if _, ok := toMonetMappers[rv.Type().Elem().String()]; ok {
+				v = rv.Elem().Interface()
+				continue
+			}
+		}
+		if valuer, ok := v.(driver.Valuer); ok {
+			val, err := valuer.Value()
+			if err != nil {
+				return nil, err
+			}
+			v = val
+			continue
+		}
+		if _, ok := v.(json.Marshaler); ok {
+			return v, nil
+		}
+
+		switch rv.Kind() {
+		case reflect.Ptr:
+			v = rv.Elem().Interface()
+			continue
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return rv.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return rv.Uint(), nil
+		case reflect.Float32:
+			return float32(rv.Float()), nil
+		case reflect.Float64:
+			return rv.Float(), nil
+		case reflect.Bool:
+			return rv.Bool(), nil
+		case reflect.String:
+			return rv.String(), nil
+		case reflect.Slice:
+			if rv.Type().Elem().Kind() == reflect.Uint8 {
+				return rv.Bytes(), nil
+			}
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("mapi: value nested too deeply: %T", v)
+}
+
+// isJSONValue reports whether a value is passed as json: a json.Marshaler,
+// a map, a slice, or a struct that opted in with json tags on its fields.
+// Other structs, like those of named types with a struct underneath, are not
+// supported.
+func isJSONValue(v Value) bool {
+	if _, ok := v.(json.Marshaler); ok {
+		return true
 	}
-	return "", fmt.Errorf("mapi: type not supported: %v", t)
+	t := reflect.TypeOf(v)
+	switch t.Kind() {
+	case reflect.Map, reflect.Slice:
+		return true
+	case reflect.Struct:
+		return hasJSONTags(t)
+	}
+	return false
+}
+
+// hasJSONTags reports whether an exported field of the struct type has a json
+// tag
+func hasJSONTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := f.Tag.Lookup("json"); ok && f.PkgPath == "" {
+			return true
+		}
+	}
+	return false
 }
@@ -6,6 +6,13 @@ package mapi
 
 import (
 	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/netip"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -32,10 +39,33 @@ func TestConvertToMonet(t *testing.T) {
 		{false, "false"},
 		{nil, "NULL"},
 		{[]byte{1, 2, 3}, "'" + string([]byte{1, 2, 3}) + "'"},
-		{Time{10, 20, 30}, "'10:20:30'"},
+		{Time{10, 20, 30, 0}, "'10:20:30'"},
+		{Time{10, 20, 30, 123456789}, "'10:20:30.123457'"},
 		{Date{2001, time.January, 2}, "'2001-01-02'"},
 		{time.Date(2001, time.January, 2, 10, 20, 30, 0, time.FixedZone("CET", 3600)),
-			"'2001-01-02 10:20:30 +0100 CET'"},
+			"TIMESTAMP WITH TIME ZONE '2001-01-02 10:20:30+01:00'"},
+		{time.Date(2001, time.January, 2, 10, 20, 30, 123456789, time.UTC),
+			"TIMESTAMP WITH TIME ZONE '2001-01-02 10:20:30.123457+00:00'"},
+		{Decimal{big.NewInt(-12340), 3}, "-12.340"},
+		{big.NewRat(1, 8), "0.125"},
+		{uint64(1 << 63), "9223372036854775808"},
+		{uint8(8), "8"},
+		{new(big.Int).Lsh(big.NewInt(1), 100), "1267650600228229401496703205376"},
+		{UUID{0x6c, 0x49, 0x86, 0x9d, 0x45, 0xdc, 0x4b, 0x3f, 0x9d, 0x6d, 0x2a, 0x3e, 0x47, 0xba, 0x3e, 0x0a},
+			"'6c49869d-45dc-4b3f-9d6d-2a3e47ba3e0a'"},
+		{json.RawMessage(`{"a": "it's"}`), `'{"a":"it\'s"}'`},
+		{map[string]int{"a": 1}, `'{"a":1}'`},
+		{[]int{1, 2}, `'[1,2]'`},
+		{90 * time.Minute, "INTERVAL '5400' SECOND"},
+		{-1500 * time.Millisecond, "INTERVAL '-1.5' SECOND"},
+		{MonthInterval(14), "INTERVAL '14' MONTH"},
+		{netip.MustParseAddr("192.168.1.5"), "'192.168.1.5'"},
+		{netip.MustParsePrefix("192.168.1.0/24"), "'192.168.1.0/24'"},
+		{Inet{netip.MustParsePrefix("10.0.0.1/32")}, "'10.0.0.1'"},
+		{&url.URL{Scheme: "https", Host: "www.monetdb.org", Path: "/Documentation"}, "'https://www.monetdb.org/Documentation'"},
+		{struct {
+			Name string `json:"name"`
+		}{"x"}, `'{"name":"x"}'`},
 	}
 
 	for _, c := range tcs {
@@ -48,7 +78,73 @@ func TestConvertToMonet(t *testing.T) {
 	}
 }
 
+type userID int64
+
+type label string
+
+type celsius float32
+
+type loopValuer struct{}
+
+func (v loopValuer) Value() (driver.Value, error) {
+	return v, nil
+}
+
+type failingValuer struct{}
+
+func (failingValuer) Value() (driver.Value, error) {
+	return nil, errors.New("no value")
+}
+
+func TestConvertToMonetNormalized(t *testing.T) {
+	id := userID(42)
+	var nilID *userID
+	s := "it's"
+	type tc struct {
+		v Value
+		e string
+	}
+	var tcs = []tc{
+		{userID(42), "42"},
+		{&id, "42"},
+		{nilID, "NULL"},
+		{label("it's"), "'it\\'s'"},
+		{celsius(21.5), "21.5"},
+		{&s, "'it\\'s'"},
+		{uint16(16), "16"},
+		{sql.NullString{String: "x", Valid: true}, "'x'"},
+		{sql.NullString{}, "NULL"},
+		{sql.NullInt64{Int64: 64, Valid: true}, "64"},
+		{sql.NullBool{Bool: true, Valid: true}, "true"},
+		{sql.NullFloat64{Float64: 6.4, Valid: true}, "6.4"},
+		{sql.NullTime{Time: time.Date(2001, time.January, 2, 10, 20, 30, 0, time.UTC), Valid: true},
+			"TIMESTAMP WITH TIME ZONE '2001-01-02 10:20:30+00:00'"},
+		{&Decimal{big.NewInt(125), 2}, "1.25"},
+	}
+
+	for _, c := range tcs {
+		s, err := ConvertToMonet(c.v)
+		if err != nil {
+			t.Errorf("Error converting value: %v -> %v", c.v, err)
+		} else if s != c.e {
+			t.Errorf("Invalid value: %s, expected: %s", s, c.e)
+		}
+	}
+}
+
+func TestConvertToMonetErrors(t *testing.T) {
+	type untagged struct{ Name string }
+	type namedTime time.Time
+	for _, v := range []Value{json.RawMessage("{"), netip.Addr{}, loopValuer{}, failingValuer{}, make(chan int),
+		untagged{"x"}, namedTime(time.Now())} {
+		if s, err := ConvertToMonet(v); err == nil {
+			t.Errorf("Converting %v did not fail: %s", v, s)
+		}
+	}
+}
+
 func TestConvertToGo(t *testing.T) {
+	hugeint, _ := new(big.Int).SetString("-170141183460469231731687303715884105727", 10)
 	type tc struct {
 		v string
 		t string
@@ -62,15 +158,22 @@ func TestConvertToGo(t *testing.T) {
 		{"32", "mediumint", int32(32)},
 		{"64", "bigint", int64(64)},
 		{"64", "longint", int64(64)},
-		{"64", "hugeint", int64(64)},
+		{"64", "hugeint", big.NewInt(64)},
+		{"-170141183460469231731687303715884105727", "hugeint", hugeint},
 		{"64", "serial", int64(64)},
 		{"3.2", "float", float64(3.2)},
 		{"3.2", "real", float32(3.2)},
 		{"6.4", "double", float64(6.4)},
-		{"6.4", "decimal", float64(6.4)},
+		{"6.4", "decimal", Decimal{big.NewInt(64), 1}},
+		{"-0.0012", "decimal", Decimal{big.NewInt(-12), 4}},
 		{"true", "boolean", true},
 		{"false", "boolean", false},
-		{"10:20:30", "time", Time{10, 20, 30}},
+		{"10:20:30", "time", Time{10, 20, 30, 0}},
+		{"10:20:30.123456", "time", Time{10, 20, 30, 123456000}},
+		{"10:20:30.5+01:00", "timetz", time.Date(1970, time.January, 1, 10, 20, 30, 500000000, time.FixedZone("", 3600))},
+		{"2001-01-02 10:20:30.000001", "timestamp", time.Date(2001, time.January, 2, 10, 20, 30, 1000, time.UTC)},
+		{"2001-01-02 10:20:30.25-05:30", "timestamptz",
+			time.Date(2001, time.January, 2, 10, 20, 30, 250000000, time.FixedZone("", -5*3600-1800))},
 		{"2001-01-02", "date", Date{2001, time.January, 2}},
 		{"'string'", "char", "string"},
 		{"'string'", "varchar", "string"},
@@ -79,6 +182,18 @@ func TestConvertToGo(t *testing.T) {
 		{"'quoted \\\\\\'string\\\\\\''", "char", "quoted \\'string\\'"},
 		{"'back\\\\slashed'", "char", "back\\slashed"},
 		{"'ABC'", "blob", []uint8{0x41, 0x42, 0x43}},
+		{"6c49869d-45dc-4b3f-9d6d-2a3e47ba3e0a", "uuid",
+			UUID{0x6c, 0x49, 0x86, 0x9d, 0x45, 0xdc, 0x4b, 0x3f, 0x9d, 0x6d, 0x2a, 0x3e, 0x47, 0xba, 0x3e, 0x0a}},
+		{`"{\"a\": 1}"`, "json", json.RawMessage(`{"a": 1}`)},
+		{`"{\"a\":\"it's\"}"`, "json", json.RawMessage(`{"a":"it's"}`)},
+		{`"it's \"quoted\""`, "varchar", `it's "quoted"`},
+		{`[1, 2]`, "json", json.RawMessage(`[1, 2]`)},
+		{"5400.000", "sec_interval", 90 * time.Minute},
+		{"-1.500", "sec_interval", -1500 * time.Millisecond},
+		{"86400.000", "day_interval", 24 * time.Hour},
+		{"14", "month_interval", MonthInterval(14)},
+		{"192.168.1.5", "inet", Inet{netip.MustParsePrefix("192.168.1.5/32")}},
+		{`"192.168.1.0/24"`, "inet", Inet{netip.MustParsePrefix("192.168.1.0/24")}},
 		{"NULL", "varchar", nil},
 		{"NULL", "float32", nil},
 		{"NULL", "float64", nil},
@@ -93,6 +208,21 @@ func TestConvertToGo(t *testing.T) {
 			switch val := v.(type) {
 			case []byte:
 				ok = compareByteArray(t, val, c.e)
+			case json.RawMessage:
+				e, isJSON := c.e.(json.RawMessage)
+				ok = isJSON && bytes.Equal(val, e)
+			case *big.Int:
+				e, isBigInt := c.e.(*big.Int)
+				ok = isBigInt && val.Cmp(e) == 0
+			case time.Time:
+				// The offset is kept, not only the instant
+				e, isTime := c.e.(time.Time)
+				_, offset := val.Zone()
+				_, expectedOffset := e.Zone()
+				ok = isTime && val.Equal(e) && offset == expectedOffset
+			case Decimal:
+				e, isDecimal := c.e.(Decimal)
+				ok = isDecimal && val.Scale == e.Scale && val.Unscaled.Cmp(e.Unscaled) == 0
 			default:
 				ok = v == c.e
 			}
@@ -111,3 +241,37 @@ func compareByteArray(t *testing.T, val []byte, e Value) bool {
 		return false
 	}
 }
+
+func TestConvertToGoErrors(t *testing.T) {
+	type tc struct {
+		v string
+		t string
+	}
+	var tcs = []tc{
+		{"10:20:30", "timetz"},
+		{"2001-01-02 10:20:30", "timestamptz"},
+		{"2001-01-02 10:20:30+1:00", "timestamptz"},
+		{"2001-01-02 25:20:30", "timestamp"},
+		{"2001-13-02", "date"},
+	}
+
+	for _, c := range tcs {
+		if v, err := convertToGo(c.v, c.t); err == nil {
+			t.Errorf("Converting %s to %s did not fail: %v", c.v, c.t, v)
+		}
+	}
+}
+
+func TestConvertTimeToMonet(t *testing.T) {
+	// A time from time.Now has a reading of the monotonic clock, which is
+	// printed by its String method
+	now := time.Now()
+	s, err := ConvertToMonet(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "TIMESTAMP WITH TIME ZONE '" + now.Round(time.Microsecond).Format("2006-01-02 15:04:05.999999-07:00") + "'"
+	if s != expected {
+		t.Errorf("Invalid value: %s, expected: %s", s, expected)
+	}
+}
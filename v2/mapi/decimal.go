@@ -0,0 +1,155 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal represents MonetDB's decimal datatype. The value is Unscaled
+// divided by 10 to the power of Scale, so the digits are kept exactly. A
+// negative Scale multiplies Unscaled by a power of 10. The zero value is 0.
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int
+}
+
+// ParseDecimal parses a decimal number like "-12.340". The scale is the
+// number of digits after the decimal point.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	digits := s
+	scale := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits = s[:i] + s[i+1:]
+		scale = len(s) - i - 1
+	}
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("mapi: invalid decimal: %q", s)
+	}
+	return Decimal{unscaled, scale}, nil
+}
+
+// NewDecimal returns the decimal with the given scale that is nearest to the
+// rational number.
+func NewDecimal(r *big.Rat, scale int) Decimal {
+	if scale < 0 {
+		// Rounded to a multiple of 10 to the power of -scale
+		factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-scale)), nil)
+		d := NewDecimal(new(big.Rat).Quo(r, new(big.Rat).SetInt(factor)), 0)
+		d.Scale = scale
+		return d
+	}
+	d, _ := ParseDecimal(r.FloatString(scale))
+	return d
+}
+
+// String returns the decimal number, with Scale digits after the decimal
+// point.
+func (d Decimal) String() string {
+	unscaled := d.unscaled()
+	if d.Scale < 0 && unscaled.Sign() != 0 {
+		return unscaled.String() + strings.Repeat("0", -d.Scale)
+	}
+	if d.Scale <= 0 {
+		return unscaled.String()
+	}
+	s := new(big.Int).Abs(unscaled).String()
+	if len(s) <= d.Scale {
+		s = strings.Repeat("0", d.Scale-len(s)+1) + s
+	}
+	s = s[:len(s)-d.Scale] + "." + s[len(s)-d.Scale:]
+	if unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Rat returns the value of the decimal as a rational number.
+func (d Decimal) Rat() *big.Rat {
+	r := new(big.Rat).SetInt(d.unscaled())
+	if d.Scale > 0 {
+		denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.Scale)), nil)
+		r.Quo(r, new(big.Rat).SetInt(denom))
+	} else if d.Scale < 0 {
+		factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-d.Scale)), nil)
+		r.Mul(r, new(big.Rat).SetInt(factor))
+	}
+	return r
+}
+
+// Float64 returns the nearest float64 value of the decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+func (d Decimal) unscaled() *big.Int {
+	if d.Unscaled == nil {
+		return new(big.Int)
+	}
+	return d.Unscaled
+}
+
+// Scan implements the sql.Scanner interface. Decimal columns are returned
+// as text, which is parsed without loss of precision.
+func (d *Decimal) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case Decimal:
+		*d = v
+	case string:
+		*d, err = ParseDecimal(v)
+	case []byte:
+		*d, err = ParseDecimal(string(v))
+	case int64:
+		*d = Decimal{big.NewInt(v), 0}
+	case float64:
+		*d, err = ParseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+	case nil:
+		err = fmt.Errorf("mapi: cannot scan NULL into Decimal")
+	default:
+		err = fmt.Errorf("mapi: cannot scan %T into Decimal", src)
+	}
+	return err
+}
+
+// Value implements the driver.Valuer interface.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// exactDecimal returns a rational number as a decimal, when it has a finite
+// number of digits after the decimal point
+func exactDecimal(r *big.Rat) (Decimal, error) {
+	// The denominator of a decimal only has the factors 2 and 5
+	denom := new(big.Int).Set(r.Denom())
+	scale := 0
+	for _, f := range []int64{2, 5} {
+		factor := big.NewInt(f)
+		count := 0
+		mod := new(big.Int)
+		for {
+			q, m := new(big.Int).QuoRem(denom, factor, mod)
+			if m.Sign() != 0 {
+				break
+			}
+			denom = q
+			count++
+		}
+		if count > scale {
+			scale = count
+		}
+	}
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return Decimal{}, fmt.Errorf("mapi: %s has no exact decimal representation", r.RatString())
+	}
+	return NewDecimal(r, scale), nil
+}
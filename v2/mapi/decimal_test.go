@@ -0,0 +1,76 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDecimal(t *testing.T) {
+	t.Run("Verify parsing and formatting keeps the digits", func(t *testing.T) {
+		for _, s := range []string{"0", "12.340", "-0.005", "123456789012345678901234567890.12", "-7"} {
+			d, err := ParseDecimal(s)
+			if err != nil {
+				t.Errorf("Error parsing %s: %v", s, err)
+			} else if d.String() != s {
+				t.Errorf("Invalid decimal: %s, expected: %s", d.String(), s)
+			}
+		}
+	})
+
+	t.Run("Verify invalid decimals", func(t *testing.T) {
+		for _, s := range []string{"", ".", "1.2.3", "1e5", "abc"} {
+			if _, err := ParseDecimal(s); err == nil {
+				t.Errorf("Parsing %q did not fail", s)
+			}
+		}
+	})
+
+	t.Run("Verify conversion to a rational number", func(t *testing.T) {
+		d := Decimal{big.NewInt(-125), 2}
+		if d.Rat().Cmp(big.NewRat(-5, 4)) != 0 {
+			t.Errorf("Invalid rational number: %s", d.Rat())
+		}
+		if d.Float64() != -1.25 {
+			t.Errorf("Invalid float: %v", d.Float64())
+		}
+		if NewDecimal(big.NewRat(2, 3), 3).String() != "0.667" {
+			t.Errorf("Invalid rounding: %s", NewDecimal(big.NewRat(2, 3), 3))
+		}
+		// A negative scale multiplies by a power of 10
+		n := Decimal{big.NewInt(-12), -3}
+		if n.String() != "-12000" || n.Rat().Cmp(big.NewRat(-12000, 1)) != 0 || n.Float64() != -12000 {
+			t.Errorf("Invalid negative scale: %s, %s", n, n.Rat())
+		}
+		if NewDecimal(big.NewRat(12351, 1), -2).String() != "12400" {
+			t.Errorf("Invalid rounding: %s", NewDecimal(big.NewRat(12351, 1), -2))
+		}
+		var zero Decimal
+		if zero.String() != "0" {
+			t.Errorf("Invalid zero value: %s", zero)
+		}
+	})
+
+	t.Run("Verify scanning", func(t *testing.T) {
+		var d Decimal
+		for _, src := range []interface{}{"1.50", []byte("1.50"), Decimal{big.NewInt(150), 2}} {
+			if err := d.Scan(src); err != nil {
+				t.Error(err)
+			} else if d.String() != "1.50" {
+				t.Errorf("Invalid scanned value: %s", d)
+			}
+		}
+		if err := d.Scan(nil); err == nil {
+			t.Error("Scanning NULL did not fail")
+		}
+	})
+
+	t.Run("Verify a rational number without a decimal representation", func(t *testing.T) {
+		if _, err := ConvertToMonet(big.NewRat(1, 3)); err == nil {
+			t.Error("Converting 1/3 did not fail")
+		}
+	})
+}
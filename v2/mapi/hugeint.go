@@ -0,0 +1,68 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// HugeInt represents MonetDB's hugeint datatype, a 128 bit integer. The zero
+// value is 0.
+type HugeInt struct {
+	Int *big.Int
+}
+
+// ParseHugeInt parses an integer like "-170141183460469231731687303715884105727"
+func ParseHugeInt(s string) (HugeInt, error) {
+	s = strings.TrimSpace(s)
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return HugeInt{}, fmt.Errorf("mapi: invalid hugeint: %q", s)
+	}
+	return HugeInt{i}, nil
+}
+
+// String returns the integer in decimal notation.
+func (h HugeInt) String() string {
+	return h.bigInt().String()
+}
+
+func (h HugeInt) bigInt() *big.Int {
+	if h.Int == nil {
+		return new(big.Int)
+	}
+	return h.Int
+}
+
+// Scan implements the sql.Scanner interface. Hugeint columns are returned as
+// text, which is parsed without loss of precision.
+func (h *HugeInt) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case HugeInt:
+		*h = v
+	case *big.Int:
+		*h = HugeInt{new(big.Int).Set(v)}
+	case string:
+		*h, err = ParseHugeInt(v)
+	case []byte:
+		*h, err = ParseHugeInt(string(v))
+	case int64:
+		*h = HugeInt{big.NewInt(v)}
+	case nil:
+		err = fmt.Errorf("mapi: cannot scan NULL into HugeInt")
+	default:
+		err = fmt.Errorf("mapi: cannot scan %T into HugeInt", src)
+	}
+	return err
+}
+
+// Value implements the driver.Valuer interface.
+func (h HugeInt) Value() (driver.Value, error) {
+	return h.String(), nil
+}
@@ -0,0 +1,71 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestHugeInt(t *testing.T) {
+	t.Run("Verify parsing and formatting keeps the digits", func(t *testing.T) {
+		for _, s := range []string{"0", "42", "-170141183460469231731687303715884105727"} {
+			h, err := ParseHugeInt(s)
+			if err != nil {
+				t.Errorf("Error parsing %s: %v", s, err)
+			} else if h.String() != s {
+				t.Errorf("Invalid hugeint: %s, expected: %s", h.String(), s)
+			}
+		}
+		var zero HugeInt
+		if zero.String() != "0" {
+			t.Errorf("Invalid zero value: %s", zero)
+		}
+	})
+
+	t.Run("Verify invalid hugeints", func(t *testing.T) {
+		for _, s := range []string{"", "1.5", "abc"} {
+			if _, err := ParseHugeInt(s); err == nil {
+				t.Errorf("Parsing %q did not fail", s)
+			}
+		}
+	})
+
+	t.Run("Verify scanning", func(t *testing.T) {
+		var h HugeInt
+		large := "170141183460469231731687303715884105727"
+		for _, src := range []interface{}{large, []byte(large), HugeInt{mustBigInt(large)}, mustBigInt(large)} {
+			if err := h.Scan(src); err != nil {
+				t.Error(err)
+			} else if h.String() != large {
+				t.Errorf("Invalid scanned value: %s", h)
+			}
+		}
+		if err := h.Scan(int64(-7)); err != nil || h.String() != "-7" {
+			t.Errorf("Invalid scanned value: %s, %v", h, err)
+		}
+		if err := h.Scan(nil); err == nil {
+			t.Error("Scanning NULL did not fail")
+		}
+	})
+
+	t.Run("Verify conversion to a literal", func(t *testing.T) {
+		s, err := ConvertToMonet(HugeInt{mustBigInt("-170141183460469231731687303715884105727")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != "-170141183460469231731687303715884105727" {
+			t.Errorf("Unexpected literal %s", s)
+		}
+		if s, err := convertParameter(HugeInt{big.NewInt(5)}, Parameter{"hugeint", 128, 0}); err != nil || s != "5" {
+			t.Errorf("Unexpected parameter %s, %v", s, err)
+		}
+	})
+}
+
+func mustBigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 10)
+	return i
+}
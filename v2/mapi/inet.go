@@ -0,0 +1,77 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Inet represents MonetDB's inet datatype, an address with an optional
+// netmask. An address without a netmask is a prefix with all its bits.
+type Inet struct {
+	Prefix netip.Prefix
+}
+
+// ParseInet parses an address like "192.168.1.5" or "192.168.1.0/24".
+func ParseInet(s string) (Inet, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return Inet{}, fmt.Errorf("mapi: invalid inet: %q", s)
+		}
+		return Inet{p}, nil
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return Inet{}, fmt.Errorf("mapi: invalid inet: %q", s)
+	}
+	return Inet{netip.PrefixFrom(a, a.BitLen())}, nil
+}
+
+// Addr returns the address, without the netmask.
+func (i Inet) Addr() netip.Addr {
+	return i.Prefix.Addr()
+}
+
+// String returns the address, with the netmask when it does not cover all
+// bits of the address.
+func (i Inet) String() string {
+	if i.Prefix.IsValid() && i.Prefix.Bits() == i.Prefix.Addr().BitLen() {
+		return i.Prefix.Addr().String()
+	}
+	return i.Prefix.String()
+}
+
+// Scan implements the sql.Scanner interface. Inet columns are returned as
+// text.
+func (i *Inet) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case Inet:
+		*i = v
+	case netip.Prefix:
+		*i = Inet{v}
+	case netip.Addr:
+		*i = Inet{netip.PrefixFrom(v, v.BitLen())}
+	case string:
+		*i, err = ParseInet(v)
+	case []byte:
+		*i, err = ParseInet(string(v))
+	case nil:
+		err = fmt.Errorf("mapi: cannot scan NULL into Inet")
+	default:
+		err = fmt.Errorf("mapi: cannot scan %T into Inet", src)
+	}
+	return err
+}
+
+// Value implements the driver.Valuer interface.
+func (i Inet) Value() (driver.Value, error) {
+	return i.String(), nil
+}
@@ -0,0 +1,78 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestInet(t *testing.T) {
+	t.Run("Verify parsing and formatting", func(t *testing.T) {
+		for _, s := range []string{"192.168.1.5", "192.168.1.0/24", "10.1.2.3/8", "::1", "2001:db8::/32"} {
+			i, err := ParseInet(s)
+			if err != nil {
+				t.Errorf("Error parsing %s: %v", s, err)
+			} else if i.String() != s {
+				t.Errorf("Invalid inet: %s, expected: %s", i, s)
+			}
+		}
+	})
+
+	t.Run("Verify invalid addresses", func(t *testing.T) {
+		for _, s := range []string{"", "192.168.1", "192.168.1.0/33", "localhost"} {
+			if _, err := ParseInet(s); err == nil {
+				t.Errorf("Parsing %q did not fail", s)
+			}
+		}
+	})
+
+	t.Run("Verify scanning", func(t *testing.T) {
+		addr := netip.MustParseAddr("192.168.1.5")
+		for _, src := range []interface{}{"192.168.1.5", []byte("192.168.1.5"), addr, netip.PrefixFrom(addr, 32)} {
+			var i Inet
+			if err := i.Scan(src); err != nil {
+				t.Error(err)
+			} else if i.Addr() != addr || i.Prefix.Bits() != 32 {
+				t.Errorf("Invalid scanned value: %s", i)
+			}
+		}
+		var i Inet
+		if err := i.Scan(nil); err == nil {
+			t.Error("Scanning NULL did not fail")
+		}
+	})
+}
+
+func TestURL(t *testing.T) {
+	const s = "https://www.monetdb.org/Documentation?q=url#top"
+
+	t.Run("Verify scanning", func(t *testing.T) {
+		parsed, _ := url.Parse(s)
+		for _, src := range []interface{}{s, []byte(s), parsed} {
+			var u URL
+			if err := u.Scan(src); err != nil {
+				t.Error(err)
+			} else if u.Host != "www.monetdb.org" || u.String() != s {
+				t.Errorf("Invalid scanned value: %s", u.String())
+			}
+		}
+		var u URL
+		if err := u.Scan(nil); err == nil {
+			t.Error("Scanning NULL did not fail")
+		}
+	})
+
+	t.Run("Verify converting to go", func(t *testing.T) {
+		v, err := convertToGo(`"`+s+`"`, MDB_URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u, ok := v.(*url.URL); !ok || u.String() != s {
+			t.Errorf("Invalid value: %v", v)
+		}
+	})
+}
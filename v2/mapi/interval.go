@@ -0,0 +1,96 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MonthInterval represents MonetDB's month_interval datatype, a number of
+// months. It is the type of year and month intervals, which cannot be
+// expressed as a time.Duration.
+type MonthInterval int32
+
+// Years returns the number of whole years of the interval.
+func (m MonthInterval) Years() int {
+	return int(m) / 12
+}
+
+// Months returns the number of months of the interval that are not part of a
+// whole year.
+func (m MonthInterval) Months() int {
+	return int(m) % 12
+}
+
+// String returns the interval in the form "Y-M", like the literal of an
+// INTERVAL YEAR TO MONTH.
+func (m MonthInterval) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d-%d", sign, m.Years(), m.Months())
+}
+
+// Scan implements the sql.Scanner interface. Month_interval columns are
+// returned as the number of months.
+func (m *MonthInterval) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case MonthInterval:
+		*m = v
+	case int64:
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return fmt.Errorf("mapi: month interval out of range: %d", v)
+		}
+		*m = MonthInterval(v)
+	case string:
+		return m.Scan([]byte(v))
+	case []byte:
+		i, err := strconv.ParseInt(strings.TrimSpace(string(v)), 10, 32)
+		if err != nil {
+			return fmt.Errorf("mapi: invalid month interval: %q", v)
+		}
+		*m = MonthInterval(i)
+	case nil:
+		return fmt.Errorf("mapi: cannot scan NULL into MonthInterval")
+	default:
+		return fmt.Errorf("mapi: cannot scan %T into MonthInterval", src)
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (m MonthInterval) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+// parseDuration parses the number of seconds of a sec_interval or a
+// day_interval, like "-3600.250"
+func parseDuration(s string) (time.Duration, error) {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		return 0, fmt.Errorf("mapi: invalid interval: %q", s)
+	}
+	ns := new(big.Rat).Mul(d.Rat(), big.NewRat(int64(time.Second), 1))
+	// The server has millisecond precision, so there is no rounding
+	if !ns.IsInt() || !ns.Num().IsInt64() {
+		return 0, fmt.Errorf("mapi: interval out of range: %q", s)
+	}
+	return time.Duration(ns.Num().Int64()), nil
+}
+
+// formatDuration returns the number of seconds of a duration, without
+// trailing zeros
+func formatDuration(d time.Duration) string {
+	s := Decimal{big.NewInt(int64(d)), 9}.String()
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
@@ -0,0 +1,67 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	t.Run("Verify parsing and formatting", func(t *testing.T) {
+		tcs := map[string]time.Duration{
+			"0":         0,
+			"1.5":       1500 * time.Millisecond,
+			"-0.001":    -time.Millisecond,
+			"86400":     24 * time.Hour,
+			"3600.0001": time.Hour + 100*time.Microsecond,
+		}
+		for s, expected := range tcs {
+			d, err := parseDuration(s)
+			if err != nil {
+				t.Errorf("Error parsing %s: %v", s, err)
+			} else if d != expected {
+				t.Errorf("Invalid duration: %v, expected: %v", d, expected)
+			}
+			if f := formatDuration(expected); f != s {
+				t.Errorf("Invalid seconds: %s, expected: %s", f, s)
+			}
+		}
+	})
+
+	t.Run("Verify invalid intervals", func(t *testing.T) {
+		for _, s := range []string{"", "'1.5'", "1.0000000001", "10000000000000"} {
+			if _, err := parseDuration(s); err == nil {
+				t.Errorf("Parsing %q did not fail", s)
+			}
+		}
+	})
+}
+
+func TestMonthInterval(t *testing.T) {
+	t.Run("Verify formatting", func(t *testing.T) {
+		tcs := map[MonthInterval]string{0: "0-0", 14: "1-2", -25: "-2-1"}
+		for m, expected := range tcs {
+			if s := m.String(); s != expected {
+				t.Errorf("Invalid interval: %s, expected: %s", s, expected)
+			}
+		}
+	})
+
+	t.Run("Verify scanning", func(t *testing.T) {
+		for _, src := range []interface{}{int64(14), "14", []byte("14"), MonthInterval(14)} {
+			var m MonthInterval
+			if err := m.Scan(src); err != nil {
+				t.Error(err)
+			} else if m != 14 {
+				t.Errorf("Invalid scanned value: %d", m)
+			}
+		}
+		var m MonthInterval
+		if err := m.Scan(nil); err == nil {
+			t.Error("Scanning NULL did not fail")
+		}
+	})
+}
@@ -6,17 +6,23 @@ package mapi
 
 import (
 	"bytes"
+	"context"
 	"crypto"
 	_ "crypto/md5"
 	_ "crypto/sha1"
 	_ "crypto/sha512"
+	"crypto/tls"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
 	"net"
+	"net/url"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -52,6 +58,11 @@ var (
 	mapi_MSG_MORE = string([]byte{1, 2, 10})
 )
 
+// ErrBadConn is returned when a command is not sent to the server, because
+// the connection is closed or broken. The command can safely be retried on
+// another connection.
+var ErrBadConn = errors.New("mapi: bad connection")
+
 // MapiConn is a MonetDB's MAPI connection handle.
 //
 // The values in the handle are initially set according to the values
@@ -60,10 +71,18 @@ var (
 // The final values are available after the connection is made by
 // calling the Connect() function.
 //
+// When Sock is set, the connection is made over the Unix domain socket
+// with that path, and Hostname and Port are ignored. When Hostname is empty,
+// the Unix domain socket for the port in the socket directory is tried
+// first, before connecting to localhost over TCP. When the handle is
+// created from a monetdbs:// URL, or with the tls parameter, the TCP
+// connection is encrypted with TLS.
+//
 // The State value can be either MAPI_STATE_INIT or MAPI_STATE_READY.
 type MapiConn struct {
 	Hostname string
 	Port     int
+	Sock     string
 	Username string
 	Password string
 	Database string
@@ -74,23 +93,82 @@ type MapiConn struct {
 	sizeHeader bool
 	replySize  int
 	autoCommit bool
-
-	conn *net.TCPConn
+	schema     string
+	timezone   *time.Location
+	// The zone with the offset of the time zone when it was set
+	zone *time.Location
+
+	sockDir        string
+	sockPrefix     string
+	connectTimeout time.Duration
+	loginTimeout   time.Duration
+	dialContext    func(ctx context.Context, network, addr string) (net.Conn, error)
+	tlsConfig      *tls.Config
+
+	// The settings are kept to open side connections with StopQuery
+	config        Config
+	sessionId     int
+	haveSessionId bool
+
+	// Set when the server reported that a transaction was started, and
+	// cleared when it reported that the transaction ended
+	inTransaction bool
+	session       sessionState
+
+	conn net.Conn
 }
 
 // NewMapi returns a MonetDB's MAPI connection handle.
 //
 // To establish the connection, call the Connect() function.
 func NewMapi(name string) (*MapiConn, error) {
-	var language = "sql"
 	c, err := parseDSN(name)
 	if err != nil {
 		return nil, err
 	}
+	return NewMapiFromConfig(&c)
+}
+
+// NewMapiFromConfig returns a MonetDB's MAPI connection handle for the
+// settings in the Config.
+//
+// To establish the connection, call the Connect() function.
+func NewMapiFromConfig(config *Config) (*MapiConn, error) {
+	var language = "sql"
+	c := *config
+	if err := validateConfig(c); err != nil {
+		return nil, err
+	}
+
+	if c.Port == 0 {
+		c.Port = mapi_DEFAULT_PORT
+	}
+	if c.SockDir == "" {
+		c.SockDir = mapi_DEFAULT_SOCKDIR
+	}
+	if c.SockPrefix == "" {
+		c.SockPrefix = mapi_DEFAULT_SOCKPREFIX
+	}
+	if c.ReplySize == 0 {
+		c.ReplySize = MAPI_ARRAY_SIZE
+	}
+	if c.TLS && c.Hostname == "" {
+		c.Hostname = "localhost"
+	}
+
+	tlsConfig := c.TLSConfig
+	if c.TLS && tlsConfig == nil {
+		var err error
+		tlsConfig, err = newTLSConfig(c)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return &MapiConn{
 		Hostname: c.Hostname,
 		Port:     c.Port,
+		Sock:     c.Sock,
 		Username: c.Username,
 		Password: c.Password,
 		Database: c.Database,
@@ -99,21 +177,50 @@ func NewMapi(name string) (*MapiConn, error) {
 		State: mapi_STATE_INIT,
 
 		sizeHeader: true,
-		replySize : MAPI_ARRAY_SIZE,
-		autoCommit: true,
+		replySize:  c.ReplySize,
+		autoCommit: c.AutoCommit,
+		schema:     c.Schema,
+		timezone:   c.Timezone,
+
+		sockDir:        c.SockDir,
+		sockPrefix:     c.SockPrefix,
+		connectTimeout: c.ConnectTimeout,
+		loginTimeout:   c.LoginTimeout,
+		dialContext:    c.DialContext,
+		tlsConfig:      tlsConfig,
+
+		config: c,
 	}, nil
 }
 
 // Disconnect closes the connection.
 func (c *MapiConn) Disconnect() {
 	c.State = mapi_STATE_INIT
+	c.haveSessionId = false
+	c.inTransaction = false
+	c.session = sessionState{}
 	if c.conn != nil {
 		c.conn.Close()
 		c.conn = nil
 	}
 }
 
+// Execute runs an SQL query. Statements that change the settings of the
+// session are tracked, so ResetSession can restore them.
 func (c *MapiConn) Execute(query string) (string, error) {
+	unsaved, err := c.trackSession(query)
+	if err != nil {
+		return "", err
+	}
+	r, err := c.execute(query)
+	if err == nil && unsaved {
+		c.session.lost = true
+	}
+	return r, err
+}
+
+// execute runs an SQL query that is sent by the driver itself
+func (c *MapiConn) execute(query string) (string, error) {
 	cmd := fmt.Sprintf("s%s;", query)
 	return c.cmd(cmd)
 }
@@ -123,6 +230,21 @@ func (c *MapiConn) FetchNext(queryId int, offset int, amount int) (string, error
 	return c.cmd(cmd)
 }
 
+// CloseResult tells the server that the rows of a result set with the given
+// query id are not needed anymore. The server keeps the rows that were not yet
+// exported until the result set is closed or the session ends.
+func (c *MapiConn) CloseResult(queryId int) error {
+	_, err := c.cmd(fmt.Sprintf("Xclose %d", queryId))
+	return err
+}
+
+// ReleasePrepared tells the server that the prepared statement with the
+// given id is not used anymore.
+func (c *MapiConn) ReleasePrepared(execId int) error {
+	_, err := c.cmd(fmt.Sprintf("Xrelease %d", execId))
+	return err
+}
+
 func (c *MapiConn) SetSizeHeader(enable bool) (string, error) {
 	var sizeheader int
 	if enable {
@@ -135,7 +257,16 @@ func (c *MapiConn) SetSizeHeader(enable bool) (string, error) {
 
 func (c *MapiConn) SetReplySize(size int) (string, error) {
 	cmd := fmt.Sprintf("Xreply_size %d", size)
-	return c.cmd(cmd)
+	r, err := c.cmd(cmd)
+	if err == nil {
+		c.replySize = size
+	}
+	return r, err
+}
+
+// ReplySize returns the number of rows the server sends in a single response
+func (c *MapiConn) ReplySize() int {
+	return c.replySize
 }
 
 func (c *MapiConn) SetAutoCommit(enable bool) (string, error) {
@@ -144,25 +275,130 @@ func (c *MapiConn) SetAutoCommit(enable bool) (string, error) {
 		autoCommit = 1
 	}
 	cmd := fmt.Sprintf("Xauto_commit %d", autoCommit)
-	return c.cmd(cmd)
+	r, err := c.cmd(cmd)
+	if err == nil {
+		c.autoCommit = enable
+	}
+	return r, err
+}
+
+// SetTimezone sets the time zone of the session to the current offset of the
+// location. The session keeps that offset, also when the location changes to
+// daylight saving time, until ResetSession sets the current offset.
+func (c *MapiConn) SetTimezone(loc *time.Location) error {
+	name, offset := time.Now().In(loc).Zone()
+	if err := c.SetTimezoneOffset(offset); err != nil {
+		return err
+	}
+	c.timezone = loc
+	c.zone = time.FixedZone(name, offset)
+	return nil
+}
+
+// SetTimezoneOffset sets the time zone of the session to the offset in
+// seconds east of UTC, without configuring a time zone for the session.
+func (c *MapiConn) SetTimezoneOffset(offset int) error {
+	_, err := c.execute(timezoneStatement(offset))
+	return err
+}
+
+// Timezone returns the time zone that was configured for the session, or nil
+// when the time zone of the server is used.
+func (c *MapiConn) Timezone() *time.Location {
+	return c.timezone
+}
+
+// zoneChanged reports whether the offset of the configured time zone is not
+// the offset that the session has anymore
+func (c *MapiConn) zoneChanged() bool {
+	if c.timezone == nil || c.zone == nil {
+		return false
+	}
+	_, offset := time.Now().In(c.timezone).Zone()
+	_, current := time.Time{}.In(c.zone).Zone()
+	return offset != current
+}
+
+// Ping checks that the server answers, with a command that does not change
+// the session. The connection is broken when the context is done before the
+// answer is received.
+func (c *MapiConn) Ping(ctx context.Context) error {
+	if c.State != mapi_STATE_READY {
+		return fmt.Errorf("mapi: database is not connected: %w", ErrBadConn)
+	}
+
+	stop := watchContext(ctx, c.conn)
+	_, err := c.cmd(fmt.Sprintf("Xreply_size %d", c.replySize))
+	if ctxErr := stop(); ctxErr != nil && err != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// InTransaction reports whether a transaction is open on the server. Statements
+// that start or end a transaction are answered with a transaction response,
+// which tells whether the session is back in auto commit mode.
+func (c *MapiConn) InTransaction() bool {
+	return c.inTransaction
+}
+
+// trackTransaction updates the transaction state from the transaction
+// responses in a reply. A reply to several statements can contain more than
+// one, the last one is the current state.
+func (c *MapiConn) trackTransaction(resp string) {
+	if !strings.Contains(resp, mapi_MSG_QTRANS) {
+		return
+	}
+	for _, line := range strings.Split(resp, "\n") {
+		if !strings.HasPrefix(line, mapi_MSG_QTRANS+" ") {
+			continue
+		}
+		switch strings.TrimSpace(line[len(mapi_MSG_QTRANS)+1:]) {
+		case "f":
+			c.inTransaction = true
+		case "t":
+			c.inTransaction = false
+		}
+	}
+}
+
+// IsConnected reports whether the connection can be used to send commands.
+// It returns false after Disconnect, and after a network error broke the
+// connection.
+func (c *MapiConn) IsConnected() bool {
+	return c.State == mapi_STATE_READY
+}
+
+// broken closes the connection after a network error. The protocol state is
+// unknown after such an error, so the connection cannot be used anymore.
+// The conn field is kept, because Interrupt may be called concurrently.
+func (c *MapiConn) broken() {
+	c.State = mapi_STATE_INIT
+	c.haveSessionId = false
+	c.inTransaction = false
+	c.conn.Close()
 }
 
 // Cmd sends a MAPI command to MonetDB.
 func (c *MapiConn) cmd(operation string) (string, error) {
 	if c.State != mapi_STATE_READY {
-		return "", fmt.Errorf("mapi: database is not connected")
+		return "", fmt.Errorf("mapi: database is not connected: %w", ErrBadConn)
 	}
 
 	if err := c.putBlock([]byte(operation)); err != nil {
-		return "", err
+		// The server only runs a command after it received the whole block
+		c.broken()
+		return "", fmt.Errorf("mapi: cannot send command: %v: %w", err, ErrBadConn)
 	}
 
 	r, err := c.getBlock()
 	if err != nil {
-		return "", err
+		c.broken()
+		return "", fmt.Errorf("mapi: connection lost: %w", err)
 	}
 
 	resp := string(r)
+	c.trackTransaction(resp)
 	if len(resp) == 0 {
 		return "", nil
 
@@ -186,41 +422,220 @@ func (c *MapiConn) cmd(operation string) (string, error) {
 
 // Connect starts a MAPI connection to MonetDB server.
 func (c *MapiConn) Connect() error {
+	return c.ConnectContext(context.Background())
+}
+
+// ConnectContext starts a MAPI connection to MonetDB server. The context
+// limits the time it takes to open the connection and to log in.
+func (c *MapiConn) ConnectContext(ctx context.Context) error {
+	c.inTransaction = false
+	c.session = sessionState{}
+	err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	stop := watchContext(ctx, c.conn)
+	err = c.setupSession()
+	if ctxErr := stop(); ctxErr != nil {
+		err = ctxErr
+	}
+	if err != nil {
+		c.Disconnect()
+	}
+	return err
+}
+
+// connect opens the connection and performs the login sequence
+func (c *MapiConn) connect(ctx context.Context) error {
 	if c.conn != nil {
 		c.conn.Close()
 		c.conn = nil
 	}
 
-	addr := fmt.Sprintf("%s:%d", c.Hostname, c.Port)
-	raddr, err := net.ResolveTCPAddr("tcp", addr)
-	if err != nil {
-		return err
+	// The connect timeout covers opening the connection, including the TLS handshake
+	dialCtx := ctx
+	if c.connectTimeout > 0 {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithTimeout(ctx, c.connectTimeout)
+		defer cancel()
 	}
 
-	conn, err := net.DialTCP("tcp", nil, raddr)
+	var conn net.Conn
+	var err error
+	unix := true
+	if c.Sock != "" {
+		conn, err = c.dial(dialCtx, "unix", c.Sock)
+	} else if c.Hostname == "" {
+		sock := filepath.Join(c.sockDir, fmt.Sprintf("%s%d", c.sockPrefix, c.Port))
+		conn, err = c.dial(dialCtx, "unix", sock)
+		if err != nil {
+			// The configuration is not changed, the next connect tries the socket again
+			unix = false
+			conn, err = c.connectTCP(dialCtx, "localhost")
+		}
+	} else {
+		unix = false
+		conn, err = c.connectTCP(dialCtx, c.Hostname)
+	}
 	if err != nil {
 		return err
 	}
-
-	conn.SetKeepAlive(false)
-	conn.SetNoDelay(true)
 	c.conn = conn
 
-	err = c.login()
+	if c.loginTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.loginTimeout)
+		defer cancel()
+	}
+
+	stop := watchContext(ctx, conn)
+	if unix {
+		// On a Unix domain socket the server expects the client to send the
+		// character '0' before the login sequence starts.
+		_, err = conn.Write([]byte("0"))
+	}
+	if err == nil {
+		err = c.login(ctx)
+	}
+	if ctxErr := stop(); ctxErr != nil {
+		err = ctxErr
+	}
 	if err != nil {
+		c.Disconnect()
 		return err
 	}
 
 	return nil
 }
 
+// watchContext makes reads and writes on the connection fail when the
+// context is done. The returned function stops watching the context, and
+// returns the error of the context when it was done before that.
+func watchContext(ctx context.Context, conn net.Conn) func() error {
+	deadline, ok := ctx.Deadline()
+	if ok {
+		conn.SetDeadline(deadline)
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			// A deadline in the past interrupts blocking reads and writes
+			conn.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+
+	return func() error {
+		close(done)
+		<-stopped
+		conn.SetDeadline(time.Time{})
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// The deadline of the connection can pass just before the context is done
+		if ok && !time.Now().Before(deadline) {
+			return context.DeadlineExceeded
+		}
+		return nil
+	}
+}
+
+// setupSession applies the session settings from the DSN after the login
+func (c *MapiConn) setupSession() error {
+	if c.replySize != MAPI_ARRAY_SIZE {
+		if _, err := c.SetReplySize(c.replySize); err != nil {
+			return err
+		}
+	}
+	if !c.autoCommit {
+		if _, err := c.SetAutoCommit(false); err != nil {
+			return err
+		}
+	}
+	if c.timezone != nil {
+		if err := c.SetTimezone(c.timezone); err != nil {
+			return err
+		}
+	}
+	if c.schema != "" {
+		if _, err := c.execute(fmt.Sprintf("SET SCHEMA %s", quoteIdentifier(c.schema))); err != nil {
+			return err
+		}
+	}
+	// Without the session id a cancelled query cannot be stopped, the
+	// connection is closed instead
+	if err := c.lookupSessionId(); err != nil && !c.IsConnected() {
+		return err
+	}
+	return nil
+}
+
+// timezoneStatement returns the statement that sets the time zone of the
+// session to the given offset in seconds east of UTC
+func timezoneStatement(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+	hours := offset / 3600
+	minutes := (offset % 3600) / 60
+	return fmt.Sprintf("SET TIME ZONE INTERVAL '%c%02d:%02d' HOUR TO MINUTE", sign, hours, minutes)
+}
+
+// quoteIdentifier returns the name as a delimited SQL identifier
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// dial opens a connection with the DialContext function from the Config,
+// or with a net.Dialer when there is none.
+func (c *MapiConn) dial(ctx context.Context, network string, addr string) (net.Conn, error) {
+	if c.dialContext != nil {
+		return c.dialContext(ctx, network, addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// connectTCP opens a TCP connection to the host and the configured port. The
+// TLS handshake, if any, has to complete before the context is done.
+func (c *MapiConn) connectTCP(ctx context.Context, host string) (net.Conn, error) {
+	addr := net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(c.Port))
+	conn, err := c.dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetKeepAlive(false)
+		tcpConn.SetNoDelay(true)
+	}
+
+	if c.tlsConfig == nil {
+		return conn, nil
+	}
+
+	tlsConn := tls.Client(conn, c.tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mapi: TLS handshake failed: %v", err)
+	}
+	return tlsConn, nil
+}
+
 // login starts the login sequence
-func (c *MapiConn) login() error {
-	return c.tryLogin(0)
+func (c *MapiConn) login(ctx context.Context) error {
+	return c.tryLogin(ctx, 0)
 }
 
 // tryLogin performs the login activity
-func (c *MapiConn) tryLogin(iteration int) error {
+func (c *MapiConn) tryLogin(ctx context.Context, iteration int) error {
 	challenge, err := c.getBlock()
 	if err != nil {
 		return err
@@ -231,11 +646,13 @@ func (c *MapiConn) tryLogin(iteration int) error {
 		return err
 	}
 
-	c.putBlock([]byte(response))
+	if err := c.putBlock([]byte(response)); err != nil {
+		return err
+	}
 
 	bprompt, err := c.getBlock()
 	if err != nil {
-		return nil
+		return err
 	}
 
 	prompt := strings.TrimSpace(string(bprompt))
@@ -256,22 +673,22 @@ func (c *MapiConn) tryLogin(iteration int) error {
 		t := strings.Split(prompt, " ")
 		r := strings.Split(t[0][1:], ":")
 
-		if r[1] == "merovingian" {
+		if len(r) > 1 && r[1] == "merovingian" {
 			// restart auth
 			if iteration <= 10 {
-				c.tryLogin(iteration + 1)
+				return c.tryLogin(ctx, iteration+1)
 			} else {
 				return fmt.Errorf("mapi: maximal number of redirects reached (10)")
 			}
 
-		} else if r[1] == "monetdb" {
-			c.Hostname = r[2][2:]
-			t = strings.Split(r[3], "/")
-			port, _ := strconv.ParseInt(t[0], 10, 32)
-			c.Port = int(port)
-			c.Database = t[1]
+		} else if len(r) > 1 && r[1] == "monetdb" {
+			err := c.parseRedirect(t[0][1:])
+			if err != nil {
+				return err
+			}
 			c.conn.Close()
-			c.Connect()
+			c.conn = nil
+			return c.connect(ctx)
 
 		} else {
 			return fmt.Errorf("mapi: unknown redirect: %s", prompt)
@@ -285,6 +702,32 @@ func (c *MapiConn) tryLogin(iteration int) error {
 	return nil
 }
 
+// parseRedirect updates the connection parameters from a redirect to another
+// server. The redirect is either in the form mapi:monetdb://host:port/database
+// or mapi:monetdb:///path/to/socket?database=name for a Unix domain socket.
+func (c *MapiConn) parseRedirect(redirect string) error {
+	u, err := url.Parse(strings.TrimPrefix(redirect, "mapi:"))
+	if err != nil {
+		return fmt.Errorf("mapi: invalid redirect: %s", redirect)
+	}
+
+	if u.Host == "" {
+		c.Sock = u.Path
+		c.Database = u.Query().Get("database")
+		return nil
+	}
+
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return fmt.Errorf("mapi: invalid redirect: %s", redirect)
+	}
+	c.Hostname = u.Hostname()
+	c.Port = port
+	c.Sock = ""
+	c.Database = strings.TrimPrefix(u.Path, "/")
+	return nil
+}
+
 // challengeResponse produces a response given a challenge
 func (c *MapiConn) challengeResponse(challenge []byte) (string, error) {
 	t := strings.Split(string(challenge), ":")
@@ -0,0 +1,311 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseRedirect(t *testing.T) {
+	t.Run("Verify redirect to a tcp address", func(t *testing.T) {
+		c := MapiConn{Sock: "/tmp/.s.monetdb.50000"}
+		err := c.parseRedirect("mapi:monetdb://otherhost:50001/otherdb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Hostname != "otherhost" || c.Port != 50001 || c.Database != "otherdb" || c.Sock != "" {
+			t.Errorf("Unexpected connection parameters: %s %d %s %s", c.Hostname, c.Port, c.Database, c.Sock)
+		}
+	})
+
+	t.Run("Verify redirect to a unix domain socket", func(t *testing.T) {
+		c := MapiConn{Hostname: "localhost", Port: 50000}
+		err := c.parseRedirect("mapi:monetdb:///tmp/.s.monetdb.50001?database=otherdb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Sock != "/tmp/.s.monetdb.50001" || c.Database != "otherdb" {
+			t.Errorf("Unexpected connection parameters: %s %s", c.Sock, c.Database)
+		}
+	})
+
+	t.Run("Verify redirect with an invalid port", func(t *testing.T) {
+		c := MapiConn{}
+		err := c.parseRedirect("mapi:monetdb://otherhost:port/otherdb")
+		if err == nil {
+			t.Error("Expected an error for an invalid port")
+		}
+	})
+}
+
+func TestSessionStatements(t *testing.T) {
+	t.Run("Verify time zone statements", func(t *testing.T) {
+		tcs := map[int]string{
+			0:             "SET TIME ZONE INTERVAL '+00:00' HOUR TO MINUTE",
+			3600:          "SET TIME ZONE INTERVAL '+01:00' HOUR TO MINUTE",
+			-90 * 60:      "SET TIME ZONE INTERVAL '-01:30' HOUR TO MINUTE",
+			5*3600 + 2700: "SET TIME ZONE INTERVAL '+05:45' HOUR TO MINUTE",
+		}
+		for offset, expected := range tcs {
+			if s := timezoneStatement(offset); s != expected {
+				t.Errorf("Unexpected statement: %s, expected: %s", s, expected)
+			}
+		}
+	})
+
+	t.Run("Verify quoted identifiers", func(t *testing.T) {
+		if s := quoteIdentifier(`my "schema"`); s != `"my ""schema"""` {
+			t.Errorf("Unexpected identifier: %s", s)
+		}
+	})
+}
+
+func TestConnectContext(t *testing.T) {
+	t.Run("Verify login is interrupted when the context is done", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+
+		config := NewConfig()
+		config.Hostname = "localhost"
+		config.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if network != "tcp" || addr != "localhost:50000" {
+				t.Errorf("Unexpected address: %s %s", network, addr)
+			}
+			return client, nil
+		}
+		c, err := NewMapiFromConfig(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The server never sends the login challenge
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err = c.ConnectContext(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if c.State != mapi_STATE_INIT {
+			t.Error("Connection should not be ready")
+		}
+	})
+
+	t.Run("Verify login timeout", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+
+		config := NewConfig()
+		config.Hostname = "localhost"
+		config.LoginTimeout = 50 * time.Millisecond
+		config.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if network != "tcp" {
+				t.Errorf("Unexpected network: %s", network)
+			}
+			return client, nil
+		}
+		c, err := NewMapiFromConfig(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = c.ConnectContext(context.Background())
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Verify the connect timeout covers the TLS handshake", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+
+		config := NewConfig()
+		config.Hostname = "localhost"
+		config.TLS = true
+		config.TLSConfig = &tls.Config{ServerName: "localhost"}
+		config.ConnectTimeout = 100 * time.Millisecond
+		config.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			// Most of the timeout is spent opening the connection
+			time.Sleep(80 * time.Millisecond)
+			return client, nil
+		}
+		c, err := NewMapiFromConfig(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The server never answers the handshake
+		start := time.Now()
+		if err := c.ConnectContext(context.Background()); err == nil {
+			t.Error("Expected an error for the TLS handshake")
+		}
+		if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+			t.Errorf("Connecting took %v", elapsed)
+		}
+	})
+
+	t.Run("Verify the socket is tried again after falling back to tcp", func(t *testing.T) {
+		var networks []string
+		config := NewConfig()
+		config.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			networks = append(networks, network)
+			if network == "tcp" && addr != "localhost:50000" {
+				t.Errorf("Unexpected address: %s", addr)
+			}
+			return nil, errors.New("refused")
+		}
+		c, err := NewMapiFromConfig(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c.ConnectContext(context.Background())
+		c.ConnectContext(context.Background())
+		if len(networks) != 4 || networks[2] != "unix" || networks[3] != "tcp" {
+			t.Errorf("Unexpected connection attempts: %v", networks)
+		}
+		if c.Hostname != "" {
+			t.Errorf("Hostname should not change: %s", c.Hostname)
+		}
+	})
+
+	t.Run("Verify dial error is returned", func(t *testing.T) {
+		config := NewConfig()
+		config.Hostname = "localhost"
+		dialErr := errors.New("no route")
+		config.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, dialErr
+		}
+		c, err := NewMapiFromConfig(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := c.ConnectContext(context.Background()); !errors.Is(err, dialErr) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestBrokenConnection(t *testing.T) {
+	t.Run("Verify command on a closed connection", func(t *testing.T) {
+		c := MapiConn{State: mapi_STATE_INIT}
+		if _, err := c.Execute("SELECT 1"); !errors.Is(err, ErrBadConn) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Verify command that cannot be sent", func(t *testing.T) {
+		server, client := net.Pipe()
+		server.Close()
+		c := MapiConn{State: mapi_STATE_READY, conn: client}
+		if _, err := c.Execute("SELECT 1"); !errors.Is(err, ErrBadConn) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if c.IsConnected() {
+			t.Error("Connection should be broken")
+		}
+	})
+
+	t.Run("Verify connection lost while waiting for the answer", func(t *testing.T) {
+		server, client := net.Pipe()
+		c := MapiConn{State: mapi_STATE_READY, conn: client}
+		go func() {
+			// Read the command, and close the connection without answering
+			buf := make([]byte, 64)
+			for n := 0; n < len("sSELECT 1;")+2; {
+				r, err := server.Read(buf)
+				if err != nil {
+					break
+				}
+				n += r
+			}
+			server.Close()
+		}()
+
+		_, err := c.Execute("SELECT 1")
+		if err == nil || errors.Is(err, ErrBadConn) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if c.IsConnected() {
+			t.Error("Connection should be broken")
+		}
+	})
+}
+
+// serveBlocks reads the blocks that the client sends, and answers each of
+// them with the next response. It stops without answering when there are no
+// responses left.
+func serveBlocks(server net.Conn, responses ...string) {
+	c := MapiConn{State: mapi_STATE_READY, conn: server}
+	for _, response := range responses {
+		if _, err := c.getBlock(); err != nil {
+			return
+		}
+		if err := c.putBlock([]byte(response)); err != nil {
+			return
+		}
+	}
+	c.getBlock()
+}
+
+func TestPing(t *testing.T) {
+	t.Run("Verify ping on a responsive server", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		go serveBlocks(server, "")
+		c := MapiConn{State: mapi_STATE_READY, conn: client}
+
+		if err := c.Ping(context.Background()); err != nil {
+			t.Error(err)
+		}
+		if !c.IsConnected() {
+			t.Error("Connection should still be usable")
+		}
+	})
+
+	t.Run("Verify ping honors the context deadline", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		go serveBlocks(server)
+		c := MapiConn{State: mapi_STATE_READY, conn: client}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := c.Ping(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if c.IsConnected() {
+			t.Error("Connection should be broken")
+		}
+	})
+
+	t.Run("Verify ping on a closed connection", func(t *testing.T) {
+		c := MapiConn{State: mapi_STATE_INIT}
+		if err := c.Ping(context.Background()); !errors.Is(err, ErrBadConn) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestCloseResult(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	commands := make(chan string, 2)
+	go recordCommands(server, commands, "")
+	c := MapiConn{State: mapi_STATE_READY, conn: client}
+
+	if err := c.CloseResult(42); err != nil {
+		t.Error(err)
+	}
+	client.Close()
+	if cmd := <-commands; cmd != "Xclose 42" {
+		t.Errorf("Unexpected command %q", cmd)
+	}
+}
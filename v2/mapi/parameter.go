@@ -0,0 +1,271 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The number of bits of the integer types
+var integerBits = map[string]int{
+	MDB_TINYINT:   8,
+	MDB_SMALLINT:  16,
+	MDB_SHORTINT:  16,
+	MDB_INT:       32,
+	MDB_MEDIUMINT: 32,
+	MDB_WRD:       32,
+	MDB_BIGINT:    64,
+	MDB_LONGINT:   64,
+	MDB_SERIAL:    64,
+	MDB_HUGEINT:   128,
+}
+
+// convertParameter converts a value to a literal for a placeholder of a
+// prepared statement. The type that PREPARE reported for the placeholder is
+// used to check the value, and to write a literal of that type. Values for
+// types that are not checked are converted with ConvertToMonet.
+func convertParameter(v Value, p Parameter) (string, error) {
+	v, err := NormalizeValue(v)
+	if err != nil {
+		return "", err
+	}
+	if v == nil {
+		return "NULL", nil
+	}
+
+	if bits, ok := integerBits[p.Type]; ok {
+		return integerParameter(v, p, bits)
+	}
+
+	switch p.Type {
+	case MDB_DECIMAL:
+		return decimalParameter(v, p)
+	case MDB_REAL, MDB_DOUBLE, MDB_FLOAT:
+		return floatParameter(v, p)
+	case MDB_BOOLEAN:
+		return booleanParameter(v, p)
+	case MDB_CHAR, MDB_VARCHAR, MDB_CLOB:
+		return stringParameter(v, p)
+	case MDB_BLOB:
+		return blobParameter(v, p)
+	case MDB_DATE, MDB_TIME, MDB_TIMETZ, MDB_TIMESTAMP, MDB_TIMESTAMPTZ:
+		return temporalParameter(v, p)
+	}
+	return ConvertToMonet(v)
+}
+
+func parameterTypeError(v Value, p Parameter) error {
+	return fmt.Errorf("cannot convert %T to %s", v, p.Type)
+}
+
+// integerValue returns the value of an integer, or a string with an integer
+func integerValue(v Value) (*big.Int, bool) {
+	switch val := v.(type) {
+	case int:
+		return big.NewInt(int64(val)), true
+	case int8:
+		return big.NewInt(int64(val)), true
+	case int16:
+		return big.NewInt(int64(val)), true
+	case int32:
+		return big.NewInt(int64(val)), true
+	case int64:
+		return big.NewInt(val), true
+	case uint:
+		return new(big.Int).SetUint64(uint64(val)), true
+	case uint8:
+		return big.NewInt(int64(val)), true
+	case uint16:
+		return big.NewInt(int64(val)), true
+	case uint32:
+		return big.NewInt(int64(val)), true
+	case uint64:
+		return new(big.Int).SetUint64(val), true
+	case *big.Int:
+		return val, val != nil
+	case HugeInt:
+		return val.bigInt(), true
+	case string:
+		return new(big.Int).SetString(strings.TrimSpace(val), 10)
+	}
+	return nil, false
+}
+
+// decimalValue returns the value of a number, or a string with a number. Infinity
+// and NaN have no decimal value.
+func decimalValue(v Value) (*big.Rat, bool) {
+	if i, ok := integerValue(v); ok {
+		return new(big.Rat).SetInt(i), true
+	}
+	// A float is taken as the shortest decimal that represents it, so that
+	// 1.005 is rounded like the literal 1.005, and not like 1.00499999...
+	switch val := v.(type) {
+	case Decimal:
+		return val.Rat(), true
+	case *big.Rat:
+		return val, val != nil
+	case float32:
+		return new(big.Rat).SetString(strconv.FormatFloat(float64(val), 'g', -1, 32))
+	case float64:
+		return new(big.Rat).SetString(strconv.FormatFloat(val, 'g', -1, 64))
+	case string:
+		return new(big.Rat).SetString(strings.TrimSpace(val))
+	}
+	return nil, false
+}
+
+func integerParameter(v Value, p Parameter, bits int) (string, error) {
+	i, ok := integerValue(v)
+	if f, isFloat := v.(float64); isFloat && f == float64(int64(f)) {
+		i, ok = big.NewInt(int64(f)), true
+	}
+	if !ok {
+		if s, isString := v.(string); isString {
+			return "", fmt.Errorf("invalid %s value: %q", p.Type, s)
+		}
+		return "", parameterTypeError(v, p)
+	}
+	// The smallest value of each type is used for NULL
+	if i.BitLen() >= bits {
+		return "", fmt.Errorf("value %s out of range for %s", i, p.Type)
+	}
+	return i.String(), nil
+}
+
+func decimalParameter(v Value, p Parameter) (string, error) {
+	r, ok := decimalValue(v)
+	if !ok {
+		if s, isString := v.(string); isString {
+			return "", fmt.Errorf("invalid %s value: %q", p.Type, s)
+		}
+		return "", parameterTypeError(v, p)
+	}
+	// The value is rounded to the scale of the decimal
+	s := r.FloatString(p.Scale)
+	digits := strings.TrimLeft(strings.SplitN(strings.TrimPrefix(s, "-"), ".", 2)[0], "0")
+	if p.Digits > 0 && len(digits) > p.Digits-p.Scale {
+		return "", fmt.Errorf("value %s out of range for decimal(%d,%d)", s, p.Digits, p.Scale)
+	}
+	return s, nil
+}
+
+func floatParameter(v Value, p Parameter) (string, error) {
+	switch val := v.(type) {
+	case float32:
+		return strconv.FormatFloat(float64(val), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s value: %q", p.Type, val)
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	}
+	if i, ok := integerValue(v); ok {
+		return i.String(), nil
+	}
+	return "", parameterTypeError(v, p)
+}
+
+func booleanParameter(v Value, p Parameter) (string, error) {
+	switch val := v.(type) {
+	case bool:
+		return strconv.FormatBool(val), nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(val))
+		if err != nil {
+			return "", fmt.Errorf("invalid %s value: %q", p.Type, val)
+		}
+		return strconv.FormatBool(b), nil
+	}
+	return "", parameterTypeError(v, p)
+}
+
+func stringParameter(v Value, p Parameter) (string, error) {
+	var s string
+	switch val := v.(type) {
+	case string:
+		s = val
+	case []byte:
+		s = string(val)
+	case Decimal:
+		s = val.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		s = fmt.Sprintf("%v", val)
+	default:
+		return ConvertToMonet(v)
+	}
+	// For clob the digits are 0, the length is not limited
+	if p.Digits > 0 && utf8.RuneCountInString(s) > p.Digits {
+		return "", fmt.Errorf("value too long for %s(%d)", p.Type, p.Digits)
+	}
+	return toQuotedString(s)
+}
+
+func blobParameter(v Value, p Parameter) (string, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return "", parameterTypeError(v, p)
+	}
+	return fmt.Sprintf("blob '%s'", hex.EncodeToString(b)), nil
+}
+
+// temporalParameter writes a literal of the type of the placeholder, so that
+// for instance only the date of a time.Time is used for a date. A time.Time
+// for a timestamp is passed with its offset, like in a query with arguments,
+// and the server converts it to the time zone of the session.
+func temporalParameter(v Value, p Parameter) (string, error) {
+	var t time.Time
+	instant := false
+	switch val := v.(type) {
+	case time.Time:
+		t = val
+		instant = true
+	case Date:
+		if p.Type == MDB_TIME || p.Type == MDB_TIMETZ {
+			return "", parameterTypeError(v, p)
+		}
+		t = val.Time()
+	case Time:
+		if p.Type != MDB_TIME && p.Type != MDB_TIMETZ {
+			return "", parameterTypeError(v, p)
+		}
+		t = val.Time()
+	case string:
+		// The server checks the value
+		return toQuotedString(val)
+	default:
+		return "", parameterTypeError(v, p)
+	}
+
+	if p.Type != MDB_DATE {
+		// Like toTimestampString, round to the precision of the server
+		t = t.Round(time.Microsecond)
+	}
+
+	switch p.Type {
+	case MDB_DATE:
+		return fmt.Sprintf("date '%s'", t.Format("2006-01-02")), nil
+	case MDB_TIME:
+		return fmt.Sprintf("time '%s'", t.Format("15:04:05.999999")), nil
+	case MDB_TIMETZ:
+		return fmt.Sprintf("time with time zone '%s'", t.Format("15:04:05.999999-07:00")), nil
+	case MDB_TIMESTAMP:
+		if !instant {
+			return fmt.Sprintf("timestamp '%s'", t.Format("2006-01-02 15:04:05.999999")), nil
+		}
+		// The server converts the instant to the time zone of the session
+		fallthrough
+	default:
+		return fmt.Sprintf("timestamp with time zone '%s'", t.Format("2006-01-02 15:04:05.999999-07:00")), nil
+	}
+}
@@ -0,0 +1,119 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConvertParameter(t *testing.T) {
+	type tc struct {
+		v Value
+		p Parameter
+		e string
+	}
+	var tcs = []tc{
+		{nil, Parameter{"int", 32, 0}, "NULL"},
+		{int64(42), Parameter{"int", 32, 0}, "42"},
+		{"42", Parameter{"int", 32, 0}, "42"},
+		{float64(42), Parameter{"bigint", 64, 0}, "42"},
+		{uint64(1 << 63), Parameter{"hugeint", 128, 0}, "9223372036854775808"},
+		{int64(5), Parameter{"decimal", 10, 2}, "5.00"},
+		{float64(1.005), Parameter{"decimal", 10, 2}, "1.01"},
+		{"-12.345", Parameter{"decimal", 10, 2}, "-12.35"},
+		{int64(3), Parameter{"double", 53, 0}, "3"},
+		{"2.5", Parameter{"double", 53, 0}, "2.5"},
+		{"true", Parameter{"boolean", 1, 0}, "true"},
+		{"it's", Parameter{"varchar", 16, 0}, "'it\\'s'"},
+		{int64(12), Parameter{"varchar", 16, 0}, "'12'"},
+		{strings.Repeat("x", 100), Parameter{"clob", 0, 0}, "'" + strings.Repeat("x", 100) + "'"},
+		{[]byte{0x01, 0xab}, Parameter{"blob", 0, 0}, "blob '01ab'"},
+		{time.Date(2001, time.January, 2, 10, 20, 30, 0, time.UTC), Parameter{"date", 0, 0}, "date '2001-01-02'"},
+		{time.Date(2001, time.January, 2, 10, 20, 30, 500000000, time.UTC), Parameter{"timestamp", 7, 0},
+			"timestamp with time zone '2001-01-02 10:20:30.5+00:00'"},
+		{time.Date(2001, time.January, 2, 10, 20, 30, 999999600, time.FixedZone("", -5*3600)), Parameter{"timestamp", 7, 0},
+			"timestamp with time zone '2001-01-02 10:20:31-05:00'"},
+		{time.Date(2001, time.January, 2, 10, 20, 30, 0, time.FixedZone("", 3600)), Parameter{"timestamptz", 7, 0},
+			"timestamp with time zone '2001-01-02 10:20:30+01:00'"},
+		{Time{10, 20, 30, 0}, Parameter{"time", 1, 0}, "time '10:20:30'"},
+		{userID(7), Parameter{"int", 32, 0}, "7"},
+		{sql.NullInt64{}, Parameter{"int", 32, 0}, "NULL"},
+		{sql.NullString{String: "x", Valid: true}, Parameter{"varchar", 16, 0}, "'x'"},
+		{Time{10, 20, 30, 123456000}, Parameter{"time", 7, 0}, "time '10:20:30.123456'"},
+		{Time{10, 20, 30, 123456789}, Parameter{"time", 7, 0}, "time '10:20:30.123457'"},
+		{time.Date(2001, time.January, 2, 10, 20, 30, 0, time.FixedZone("", -5*3600)), Parameter{"timetz", 1, 0},
+			"time with time zone '10:20:30-05:00'"},
+		{Date{2001, time.January, 2}, Parameter{"timestamp", 7, 0}, "timestamp '2001-01-02 00:00:00'"},
+	}
+
+	for _, c := range tcs {
+		s, err := convertParameter(c.v, c.p)
+		if err != nil {
+			t.Errorf("Error converting value: %v -> %v", c.v, err)
+		} else if s != c.e {
+			t.Errorf("Invalid value: %s, expected: %s", s, c.e)
+		}
+	}
+}
+
+func TestConvertParameterErrors(t *testing.T) {
+	type tc struct {
+		v Value
+		p Parameter
+	}
+	var tcs = []tc{
+		{"abc", Parameter{"int", 32, 0}},
+		{int64(1 << 31), Parameter{"int", 32, 0}},
+		{int64(128), Parameter{"tinyint", 8, 0}},
+		{float64(1.5), Parameter{"int", 32, 0}},
+		{true, Parameter{"int", 32, 0}},
+		{int64(1000), Parameter{"decimal", 4, 2}},
+		{"1,5", Parameter{"decimal", 4, 2}},
+		{"yes please", Parameter{"boolean", 1, 0}},
+		{"too long", Parameter{"varchar", 3, 0}},
+		{"0a", Parameter{"blob", 0, 0}},
+		{int64(1), Parameter{"date", 0, 0}},
+		{Time{10, 20, 30, 0}, Parameter{"date", 0, 0}},
+	}
+
+	for _, c := range tcs {
+		if s, err := convertParameter(c.v, c.p); err == nil {
+			t.Errorf("Converting %v to %s did not fail: %s", c.v, c.p.Type, s)
+		}
+	}
+}
+
+func TestCreateExecString(t *testing.T) {
+	var r ResultSet
+	r.Metadata.ExecId = 7
+	r.Parameters = []Parameter{{"int", 32, 0}, {"varchar", 4, 0}}
+
+	t.Run("Verify the arguments are converted to the parameter types", func(t *testing.T) {
+		s, err := r.CreateExecString([]Value{"1", int64(2)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != "EXEC 7 (1, '2')" {
+			t.Errorf("Unexpected exec string %s", s)
+		}
+	})
+
+	t.Run("Verify the error reports the parameter", func(t *testing.T) {
+		_, err := r.CreateExecString([]Value{int64(1), "too long"})
+		if err == nil || !strings.Contains(err.Error(), "parameter 2") {
+			t.Errorf("Unexpected error %v", err)
+		}
+	})
+	t.Run("Verify the number of arguments has to match the parameters", func(t *testing.T) {
+		for _, args := range [][]Value{{int64(1)}, {int64(1), "a", "b"}} {
+			if s, err := r.CreateExecString(args); err == nil {
+				t.Errorf("Expected an error for %d arguments: %s", len(args), s)
+			}
+		}
+	})
+}
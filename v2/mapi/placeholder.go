@@ -0,0 +1,116 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InterpolateQuery replaces the ? placeholders in the query with the
+// arguments, converted to literals with ConvertToMonet. A question mark in a
+// string literal, a quoted identifier or a comment is not a placeholder.
+func InterpolateQuery(query string, args []Value) (string, error) {
+	return interpolateQuery(query, args, ConvertToMonet)
+}
+
+// interpolateQuery replaces the placeholders with the arguments, converted
+// with the given function
+func interpolateQuery(query string, args []Value, convert func(Value) (string, error)) (string, error) {
+	var b strings.Builder
+	n := 0
+	start := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'':
+			raw := i > 0 && (query[i-1] == 'r' || query[i-1] == 'R') && !isIdentifierChar(query, i-2)
+			i = skipString(query, i, raw)
+		case c == '"':
+			i = skipQuoted(query, i, '"')
+		case c == '-' || c == '/':
+			i = skipComment(query, i)
+		case c == '?':
+			if n >= len(args) {
+				return "", fmt.Errorf("mapi: query has more placeholders than the %d arguments", len(args))
+			}
+			str, err := convert(args[n])
+			if err != nil {
+				return "", fmt.Errorf("mapi: parameter %d: %w", n+1, err)
+			}
+			b.WriteString(query[start:i])
+			b.WriteString(str)
+			start = i + 1
+			n++
+		}
+	}
+	if n != len(args) {
+		return "", fmt.Errorf("mapi: query has %d placeholders, got %d arguments", n, len(args))
+	}
+	b.WriteString(query[start:])
+	return b.String(), nil
+}
+
+// skipString returns the position of the quote that ends the string literal
+// that starts at position i. In a raw string a backslash is not an escape.
+func skipString(query string, i int, raw bool) int {
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if !raw {
+				i++
+			}
+		case '\'':
+			if i+1 < len(query) && query[i+1] == '\'' {
+				i++
+			} else {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// skipComment returns the position of the last character of the comment
+// that starts at position i, or i when no comment starts there
+func skipComment(query string, i int) int {
+	switch {
+	case strings.HasPrefix(query[i:], "--"):
+		if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+			return i + end
+		}
+		return len(query)
+	case strings.HasPrefix(query[i:], "/*"):
+		if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+			return i + end + 3
+		}
+		return len(query)
+	}
+	return i
+}
+
+// skipQuoted returns the position of the quote that ends the quoted
+// identifier that starts at position i
+func skipQuoted(query string, i int, quote byte) int {
+	for i++; i < len(query); i++ {
+		if query[i] == quote {
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+			} else {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// isIdentifierChar reports whether the character at position i can be part
+// of an identifier
+func isIdentifierChar(query string, i int) bool {
+	if i < 0 {
+		return false
+	}
+	c := query[i]
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
@@ -0,0 +1,57 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"testing"
+)
+
+func TestInterpolateQuery(t *testing.T) {
+	type tc struct {
+		q    string
+		args []Value
+		e    string
+	}
+	var tcs = []tc{
+		{"select * from t where id = ?", []Value{int64(5)}, "select * from t where id = 5"},
+		{"select ?, ?", []Value{"a'b", nil}, "select 'a\\'b', NULL"},
+		{"select '?', ?", []Value{int64(1)}, "select '?', 1"},
+		{"select 'it''s ?', ?", []Value{int64(1)}, "select 'it''s ?', 1"},
+		{"select 'back\\'slash ?', ?", []Value{int64(1)}, "select 'back\\'slash ?', 1"},
+		{"select r'raw\\', ?", []Value{int64(1)}, "select r'raw\\', 1"},
+		{"select \"odd?\"\"name\" from t where x = ?", []Value{int64(1)}, "select \"odd?\"\"name\" from t where x = 1"},
+		{"select ? -- why?\n, ?", []Value{int64(1), int64(2)}, "select 1 -- why?\n, 2"},
+		{"select /* what? */ ?", []Value{int64(1)}, "select /* what? */ 1"},
+		{"select 1", nil, "select 1"},
+	}
+
+	for _, c := range tcs {
+		s, err := InterpolateQuery(c.q, c.args)
+		if err != nil {
+			t.Errorf("Error interpolating %q: %v", c.q, err)
+		} else if s != c.e {
+			t.Errorf("Invalid query: %q, expected: %q", s, c.e)
+		}
+	}
+}
+
+func TestInterpolateQueryErrors(t *testing.T) {
+	type tc struct {
+		q    string
+		args []Value
+	}
+	var tcs = []tc{
+		{"select ?, ?", []Value{int64(1)}},
+		{"select ?", []Value{int64(1), int64(2)}},
+		{"select '?'", []Value{int64(1)}},
+		{"select ?", []Value{make(chan int)}},
+	}
+
+	for _, c := range tcs {
+		if s, err := InterpolateQuery(c.q, c.args); err == nil {
+			t.Errorf("Interpolating %q did not fail: %s", c.q, s)
+		}
+	}
+}
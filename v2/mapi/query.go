@@ -16,7 +16,7 @@ type Query struct {
 
 func (q *Query) execute(query string) (string, error) {
 	if q.Mapi == nil {
-		return "", fmt.Errorf("monetdb: database connection is closed")
+		return "", fmt.Errorf("monetdb: database connection is closed: %w", ErrBadConn)
 	}
 	return q.Mapi.Execute(query)
 }
@@ -47,6 +47,16 @@ func (q *Query) ExecuteNamedQuery(r *ResultSet, names []string, args []Value) (s
 	return q.execute(execStr)
 }
 
+// ExecutePositionalQuery runs a query with ? placeholders, which are replaced
+// by the arguments on the client
+func (q *Query) ExecutePositionalQuery(r *ResultSet, args []Value) (string, error) {
+	execStr, err := interpolateQuery(q.SqlQuery, args, r.Converters.ConvertToMonet)
+	if err != nil {
+		return "", err
+	}
+	return q.execute(execStr)
+}
+
 func (q *Query) ExecuteQuery(r *ResultSet) (string, error) {
 	return q.execute(q.SqlQuery)
 }
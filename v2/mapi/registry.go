@@ -0,0 +1,105 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Decoder converts the text of a value that the server sent to a Go value.
+// Strings and blobs are still quoted. NULL is not passed to a decoder.
+type Decoder func(string) (Value, error)
+
+// Encoder converts a Go value to a literal for a query.
+type Encoder func(Value) (string, error)
+
+// Converters holds decoders for MonetDB types and encoders for Go types,
+// which replace the built-in conversions, or add conversions for types the
+// driver does not know, like those of extension modules. The zero value has
+// no converters, and a nil *Converters uses the built-in conversions only.
+// Converters can be registered while it is in use.
+type Converters struct {
+	mu       sync.RWMutex
+	decoders map[string]Decoder
+	encoders map[reflect.Type]Encoder
+}
+
+// RegisterDecoder sets the decoder for values of a MonetDB type, for example
+// "decimal" or "inet". A nil decoder removes it, the built-in conversion is
+// then used again.
+func (c *Converters) RegisterDecoder(typeName string, d Decoder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	typeName = strings.ToLower(typeName)
+	if d == nil {
+		delete(c.decoders, typeName)
+		return
+	}
+	if c.decoders == nil {
+		c.decoders = make(map[string]Decoder)
+	}
+	c.decoders[typeName] = d
+}
+
+// RegisterEncoder sets the encoder for arguments of a Go type. Only
+// arguments of exactly that type are passed to it. A nil encoder removes it.
+func (c *Converters) RegisterEncoder(t reflect.Type, e Encoder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e == nil {
+		delete(c.encoders, t)
+		return
+	}
+	if c.encoders == nil {
+		c.encoders = make(map[reflect.Type]Encoder)
+	}
+	c.encoders[t] = e
+}
+
+// Decoder returns the decoder that was registered for a MonetDB type.
+func (c *Converters) Decoder(typeName string) (Decoder, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	d, ok := c.decoders[typeName]
+	return d, ok
+}
+
+// Encoder returns the encoder that was registered for the type of a value.
+func (c *Converters) Encoder(v Value) (Encoder, bool) {
+	if c == nil || v == nil {
+		return nil, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.encoders[reflect.TypeOf(v)]
+	return e, ok
+}
+
+// ConvertToMonet converts a value with its registered encoder, or else with
+// the built-in conversion.
+func (c *Converters) ConvertToMonet(v Value) (string, error) {
+	if e, ok := c.Encoder(v); ok {
+		return e(v)
+	}
+	return ConvertToMonet(v)
+}
+
+// convertToGo converts a value with the registered decoder for its type, or
+// else with the built-in conversion
+func (c *Converters) convertToGo(value, dataType string) (Value, error) {
+	if d, ok := c.Decoder(dataType); ok {
+		value = strings.TrimSpace(value)
+		if value == MDB_NULL {
+			return nil, nil
+		}
+		return d(value)
+	}
+	return convertToGo(value, dataType)
+}
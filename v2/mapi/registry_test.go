@@ -0,0 +1,85 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+}
+
+func TestConverters(t *testing.T) {
+	c := &Converters{}
+	c.RegisterDecoder("decimal", func(v string) (Value, error) {
+		return v, nil
+	})
+	c.RegisterDecoder("point", func(v string) (Value, error) {
+		var p point
+		_, err := fmt.Sscanf(strings.Trim(v, "\"'"), "POINT (%d %d)", &p.X, &p.Y)
+		return p, err
+	})
+	c.RegisterEncoder(reflect.TypeOf(point{}), func(v Value) (string, error) {
+		p := v.(point)
+		return fmt.Sprintf("'POINT (%d %d)'", p.X, p.Y), nil
+	})
+
+	t.Run("Verify decoders replace and extend the built-in conversions", func(t *testing.T) {
+		r := ResultSet{Converters: c}
+		var response = "&1 0 1 3 1\n" +
+			"% .%1,\t.%2,\t.%3 # table_name\n" +
+			"% %1,\t%2,\t%3 # name\n" +
+			"% decimal,\tpoint,\tint # type\n" +
+			"% 5,\t11,\t1 # length\n" +
+			"% 5 2,\t0 0,\t32 0 # typesizes\n" +
+			"[ 12.50,\t\"POINT (1 2)\",\tNULL\t]\n"
+		if err := r.StoreResult(response); err != nil {
+			t.Fatal(err)
+		}
+		if v := r.Rows[0][0]; v != "12.50" {
+			t.Errorf("Unexpected decimal %v", v)
+		}
+		if v := r.Rows[0][1]; v != (point{1, 2}) {
+			t.Errorf("Unexpected point %v", v)
+		}
+		if v := r.Rows[0][2]; v != nil {
+			t.Errorf("Unexpected NULL %v", v)
+		}
+	})
+
+	t.Run("Verify encoders replace and extend the built-in conversions", func(t *testing.T) {
+		r := ResultSet{Converters: c, Parameters: []Parameter{{"varchar", 32, 0}, {"int", 32, 0}}}
+		r.Metadata.ExecId = 3
+		s, err := r.CreateExecString([]Value{point{1, 2}, 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != "EXEC 3 ('POINT (1 2)', 5)" {
+			t.Errorf("Unexpected statement %s", s)
+		}
+		if s, err := interpolateQuery("select ?", []Value{point{3, 4}}, c.ConvertToMonet); err != nil || s != "select 'POINT (3 4)'" {
+			t.Errorf("Unexpected query %s: %v", s, err)
+		}
+	})
+
+	t.Run("Verify removed converters", func(t *testing.T) {
+		c := &Converters{}
+		c.RegisterEncoder(reflect.TypeOf(0), func(v Value) (string, error) {
+			return "0", nil
+		})
+		c.RegisterEncoder(reflect.TypeOf(0), nil)
+		if s, err := c.ConvertToMonet(42); err != nil || s != "42" {
+			t.Errorf("Unexpected value %s: %v", s, err)
+		}
+		var none *Converters
+		if s, err := none.ConvertToMonet(42); err != nil || s != "42" {
+			t.Errorf("Unexpected value %s: %v", s, err)
+		}
+	})
+}
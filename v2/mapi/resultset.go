@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type TableElement struct {
@@ -30,12 +31,27 @@ type Metadata struct {
 	ColumnCount int
 }
 
+// Parameter describes a placeholder of a prepared statement, as reported by
+// PREPARE. For decimals Digits is the precision, for strings it is the
+// maximum length.
+type Parameter struct {
+	Type   string
+	Digits int
+	Scale  int
+}
+
 type Value interface{}
 
 type ResultSet struct {
 	Metadata Metadata
 	Schema []TableElement
 	Rows [][]Value
+	// The placeholders of a prepared statement
+	Parameters []Parameter
+	// The location of timestamps without a time zone, UTC when nil
+	Timezone *time.Location
+	// The converters that replace the built-in conversions, if any
+	Converters *Converters
 }
 
 func (s *ResultSet) StoreResult(r string) error {
@@ -46,19 +62,30 @@ func (s *ResultSet) StoreResult(r string) error {
 	var precisions []int
 	var scales []int
 	var nullOks []int
+	prepare := false
 
 	for _, line := range strings.Split(r, "\n") {
+		if prepare && (strings.HasPrefix(line, mapi_MSG_Q) || line == mapi_MSG_PROMPT) {
+			// The description of the prepared statement is complete
+			if err := s.storeParameters(); err != nil {
+				return err
+			}
+			prepare = false
+		}
+
 		if strings.HasPrefix(line, mapi_MSG_INFO) {
 			// TODO log
 
-		} else if strings.HasPrefix(line, mapi_MSG_QPREPARE) {
+		} else if strings.HasPrefix(line, mapi_MSG_QTABLE) || strings.HasPrefix(line, mapi_MSG_QPREPARE) {
+			// A prepared statement is described by a table, with a row for
+			// every column of the result and for every placeholder
 			t := strings.Split(strings.TrimSpace(line[2:]), " ")
-			s.Metadata.ExecId, _ = strconv.Atoi(t[0])
-			return nil
-
-		} else if strings.HasPrefix(line, mapi_MSG_QTABLE) {
-			t := strings.Split(strings.TrimSpace(line[2:]), " ")
-			s.Metadata.QueryId, _ = strconv.Atoi(t[0])
+			if strings.HasPrefix(line, mapi_MSG_QPREPARE) {
+				prepare = true
+				s.Metadata.ExecId, _ = strconv.Atoi(t[0])
+			} else {
+				s.Metadata.QueryId, _ = strconv.Atoi(t[0])
+			}
 			s.Metadata.RowCount, _ = strconv.Atoi(t[1])
 			s.Metadata.ColumnCount, _ = strconv.Atoi(t[2])
 
@@ -148,19 +175,83 @@ func (s *ResultSet) StoreResult(r string) error {
 			s.Metadata.Offset = 0
 			s.Metadata.LastRowId = 0
 
-		} else if strings.HasPrefix(line, mapi_MSG_PROMPT) {
-			return nil
-
 		} else if strings.HasPrefix(line, mapi_MSG_ERROR) {
+			// Checked before the prompt, which is a prefix of every line
 			return fmt.Errorf("mapi: database error: %s", line[1:])
+
+		} else if strings.HasPrefix(line, mapi_MSG_PROMPT) {
+			if prepare {
+				return s.storeParameters()
+			}
+			return nil
 		}
 	}
 
 	return fmt.Errorf("mapi: unknown state: %s", r)
 }
 
+// storeParameters takes the placeholders from the table that describes a
+// prepared statement. The rows of the placeholders have no table and column.
+func (s *ResultSet) storeParameters() error {
+	columns := make(map[string]int)
+	for i, e := range s.Schema {
+		columns[e.ColumnName] = i
+	}
+	typeIndex, okType := columns["type"]
+	digitsIndex, okDigits := columns["digits"]
+	scaleIndex, okScale := columns["scale"]
+	columnIndex, okColumn := columns["column"]
+	if !okType || !okDigits || !okScale || !okColumn {
+		return fmt.Errorf("mapi: unexpected description of prepared statement")
+	}
+
+	s.Parameters = make([]Parameter, 0)
+	for _, row := range s.Rows {
+		if column, _ := row[columnIndex].(string); column != "" {
+			continue
+		}
+		var p Parameter
+		p.Type, _ = row[typeIndex].(string)
+		if v, ok := row[digitsIndex].(int32); ok {
+			p.Digits = int(v)
+		}
+		if v, ok := row[scaleIndex].(int32); ok {
+			p.Scale = int(v)
+		}
+		s.Parameters = append(s.Parameters, p)
+	}
+	return nil
+}
+
+// splitTuple returns the fields of a tuple line. The fields are separated by
+// commas, which can also occur in quoted strings.
+func splitTuple(d string) []string {
+	d = strings.TrimSpace(d)
+	d = strings.TrimSuffix(strings.TrimPrefix(d, mapi_MSG_TUPLE), "]")
+
+	items := make([]string, 0)
+	start := 0
+	quoted := false
+	for i := 0; i < len(d); i++ {
+		switch d[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				items = append(items, d[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(items, d[start:])
+}
+
 func (s *ResultSet) parseTuple(d string) ([]Value, error) {
-	items := strings.Split(d[1:len(d)-1], ",\t")
+	items := splitTuple(d)
 	if len(items) != len(s.Schema) {
 		return nil, fmt.Errorf("mapi: length of row doesn't match header")
 	}
@@ -198,18 +289,36 @@ func (s *ResultSet) updateSchema(
 }
 
 func (s *ResultSet) convert(value, dataType string) (Value, error) {
+	if _, ok := s.Converters.Decoder(dataType); ok {
+		return s.Converters.convertToGo(value, dataType)
+	}
+	if dataType == MDB_TIMESTAMP && s.Timezone != nil && strings.TrimSpace(value) != MDB_NULL {
+		return parseTemporal(timestampLayout, strings.TrimSpace(value), s.Timezone)
+	}
 	val, err := convertToGo(value, dataType)
 	return val, err
 }
 
 func (s *ResultSet) CreateExecString(args []Value) (string, error) {
+	// The arguments are converted to the types of the placeholders, which are
+	// known when the statement was prepared
+	if len(args) != len(s.Parameters) {
+		return "", fmt.Errorf("mapi: expected %d arguments, got %d", len(s.Parameters), len(args))
+	}
+
 	var b bytes.Buffer
 	b.WriteString(fmt.Sprintf("EXEC %d (", s.Metadata.ExecId))
 
 	for i, v := range args {
-		str, err := ConvertToMonet(v)
+		var str string
+		var err error
+		if _, ok := s.Converters.Encoder(v); ok {
+			str, err = s.Converters.ConvertToMonet(v)
+		} else {
+			str, err = convertParameter(v, s.Parameters[i])
+		}
 		if err != nil {
-			return "", nil
+			return "", fmt.Errorf("mapi: parameter %d: %w", i+1, err)
 		}
 		if i > 0 {
 			b.WriteString(", ")
@@ -227,9 +336,9 @@ var b bytes.Buffer
 	b.WriteString(fmt.Sprintf("%s : ( ", query))
 
 	for i, v := range args {
-		str, err := ConvertToMonet(v)
+		str, err := s.Converters.ConvertToMonet(v)
 		if err != nil {
-			return "", nil
+			return "", fmt.Errorf("mapi: parameter %s: %w", names[i], err)
 		}
 		if i > 0 {
 			b.WriteString(", ")
@@ -240,4 +349,33 @@ var b bytes.Buffer
 
 	b.WriteString(")")
 	return b.String(), nil
-}
\ No newline at end of file
+}
+
+// SplitResults splits the response to a query with several statements into
+// the responses to the separate statements. Each of them starts with a query
+// response line, and can be stored in its own ResultSet. The error of a
+// statement that failed after the first one is a response of its own, for
+// which StoreResult returns the error.
+func SplitResults(r string) []string {
+	var results []string
+	var b strings.Builder
+	query := false
+	failed := false
+	for _, line := range strings.Split(strings.TrimSuffix(r, "\n"), "\n") {
+		isError := strings.HasPrefix(line, mapi_MSG_ERROR)
+		if strings.HasPrefix(line, mapi_MSG_Q) || isError && !failed {
+			if query {
+				results = append(results, b.String())
+				b.Reset()
+			}
+			query = true
+		}
+		failed = isError
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if b.Len() > 0 {
+		results = append(results, b.String())
+	}
+	return results
+}
@@ -5,7 +5,9 @@
 package mapi
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestResultSet(t *testing.T) {
@@ -98,4 +100,131 @@ func TestResultSetStoreResult(t *testing.T) {
 		}
 	})
 
+	t.Run("Verify SplitResults with several statements", func(t *testing.T) {
+		var response = `&2 1 -1
+&1 0 1 1 1 0 0 0 0
+% .%1 # table_name
+% %1 # name
+% tinyint # type
+% 1 # length
+% 8 0 # typesizes
+[ 1	]
+&4 t
+`
+		results := SplitResults(response)
+		if len(results) != 3 {
+			t.Fatalf("Unexpected number of results %d", len(results))
+		}
+		var r ResultSet
+		if err := r.StoreResult(results[1]); err != nil {
+			t.Fatal(err)
+		}
+		if r.Metadata.ColumnCount != 1 || len(r.Rows) != 1 {
+			t.Error("Unexpected table result")
+		}
+		if results[2] != "&4 t\n" {
+			t.Errorf("Unexpected result %q", results[2])
+		}
+	})
+
+	t.Run("Verify SplitResults with a statement that failed", func(t *testing.T) {
+		var response = `&1 0 1 1 1 0 0 0 0
+% .%1 # table_name
+% %1 # name
+% tinyint # type
+% 1 # length
+% 8 0 # typesizes
+[ 1	]
+!42000!SELECT: no such table 'no_such_table'
+!42000!second line of the error
+`
+		results := SplitResults(response)
+		if len(results) != 2 {
+			t.Fatalf("Unexpected number of results %d", len(results))
+		}
+		var r ResultSet
+		if err := r.StoreResult(results[0]); err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Rows) != 1 {
+			t.Error("Unexpected table result")
+		}
+		var failed ResultSet
+		if err := failed.StoreResult(results[1]); err == nil || !strings.Contains(err.Error(), "no_such_table") {
+			t.Errorf("Unexpected error %v", err)
+		}
+	})
+
+	t.Run("Verify StoreResult stores the parameters of a prepared statement", func(t *testing.T) {
+		var r ResultSet
+		var response = "&5 3 3 6 3\n" +
+			"% .prepare,\t.prepare,\t.prepare,\t.prepare,\t.prepare,\t.prepare # table_name\n" +
+			"% type,\tdigits,\tscale,\tschema,\ttable,\tcolumn # name\n" +
+			"% varchar,\tint,\tint,\tvarchar,\tvarchar,\tvarchar # type\n" +
+			"% 7,\t2,\t1,\t0,\t5,\t4 # length\n" +
+			"% 0 0,\t32 0,\t0 0,\t0 0,\t0 0,\t0 0 # typesizes\n" +
+			"[ \"varchar\",\t16,\t0,\t\"sys\",\t\"test1\",\t\"name\"\t]\n" +
+			"[ \"decimal\",\t10,\t2,\tNULL,\tNULL,\tNULL\t]\n" +
+			"[ \"varchar\",\t16,\t0,\tNULL,\tNULL,\tNULL\t]\n"
+		err := r.StoreResult(response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Metadata.ExecId != 3 {
+			t.Errorf("Unexpected exec id %d", r.Metadata.ExecId)
+		}
+		expected := []Parameter{{"decimal", 10, 2}, {"varchar", 16, 0}}
+		if len(r.Parameters) != len(expected) {
+			t.Fatalf("Unexpected parameters %v", r.Parameters)
+		}
+		for i, p := range expected {
+			if r.Parameters[i] != p {
+				t.Errorf("Unexpected parameter %d: %v", i, r.Parameters[i])
+			}
+		}
+	})
+
+	t.Run("Verify StoreResult with commas in strings", func(t *testing.T) {
+		var r ResultSet
+		var response = "&1 0 1 2 1\n" +
+			"% .%1,\t.%2 # table_name\n" +
+			"% %1,\t%2 # name\n" +
+			"% varchar,\tint # type\n" +
+			"% 4,\t1 # length\n" +
+			"% 0 0,\t32 0 # typesizes\n" +
+			"[ \"a,\\\\b\",\t1\t]\n"
+		err := r.StoreResult(response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := r.Rows[0][0]; v != "a,\\b" {
+			t.Errorf("Unexpected value %q", v)
+		}
+	})
+
+	t.Run("Verify StoreResult with the location of timestamps", func(t *testing.T) {
+		loc, err := time.LoadLocation("Europe/Amsterdam")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := ResultSet{Timezone: loc}
+		var response = "&1 0 1 2 1\n" +
+			"% .%1,\t.%2 # table_name\n" +
+			"% %1,\t%2 # name\n" +
+			"% timestamp,\ttimestamptz # type\n" +
+			"% 26,\t32 # length\n" +
+			"% 7 0,\t7 0 # typesizes\n" +
+			"[ 2001-07-02 10:20:30.000000,\t2001-07-02 10:20:30.000000+00:00\t]\n"
+		if err := r.StoreResult(response); err != nil {
+			t.Fatal(err)
+		}
+		expected := time.Date(2001, time.July, 2, 10, 20, 30, 0, loc)
+		if v, ok := r.Rows[0][0].(time.Time); !ok || !v.Equal(expected) || v.Location() != loc {
+			t.Errorf("Unexpected timestamp %v", r.Rows[0][0])
+		}
+		// A timestamp with a time zone keeps its offset
+		if v, ok := r.Rows[0][1].(time.Time); !ok || v.Hour() != 10 {
+			t.Errorf("Unexpected timestamp %v", r.Rows[0][1])
+		}
+	})
 }
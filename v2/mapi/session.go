@@ -0,0 +1,209 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Statements that change settings of the session that can be restored
+	sessionStatement = regexp.MustCompile(`(?i)^\s*SET\s+(SCHEMA|ROLE|(LOCAL\s+)?TIME\s+ZONE|SESSION\s+AUTHORIZATION)\b`)
+	// Statements and procedures that change settings of the session that
+	// cannot be restored. A connection that ran one of them is not reused.
+	sessionProcedure = regexp.MustCompile(`(?i)^\s*(SET\s+OPTIMIZER\b|CALL\s+("?sys"?\s*\.\s*)?"?set((session|query|print)?timeout|optimizer|workerlimit|memorylimit)"?\s*\()`)
+)
+
+// splitStatements returns the statements of the query. Comments are
+// replaced by a space and string literals are left empty, so that only
+// the keywords of the statements remain to be matched.
+func splitStatements(query string) []string {
+	var statements []string
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'':
+			b.WriteString(query[start:i])
+			b.WriteString("''")
+			raw := i > 0 && (query[i-1] == 'r' || query[i-1] == 'R') && !isIdentifierChar(query, i-2)
+			i = skipString(query, i, raw)
+			start = i + 1
+		case c == '"':
+			i = skipQuoted(query, i, '"')
+		case c == '-' || c == '/':
+			if end := skipComment(query, i); end != i {
+				b.WriteString(query[start:i])
+				b.WriteByte(' ')
+				i = end
+				start = i + 1
+			}
+		case c == ';':
+			b.WriteString(query[start:i])
+			statements = append(statements, b.String())
+			b.Reset()
+			start = i + 1
+		}
+	}
+	if start < len(query) {
+		b.WriteString(query[start:])
+	}
+	return append(statements, b.String())
+}
+
+// sessionState keeps the settings of the session from before the first
+// statement that changed them
+type sessionState struct {
+	saved   bool
+	user    string
+	role    string
+	schema  string
+	changed bool
+	lost    bool
+}
+
+// SessionChanged reports whether a statement changed the settings of the
+// session since the connection was made or the session was reset.
+func (c *MapiConn) SessionChanged() bool {
+	return c.session.changed || c.session.lost
+}
+
+// trackSession is called before a query is executed. When the query changes
+// the settings of the session, the current settings are saved first. When
+// they cannot be saved, for instance in a failed transaction, unsaved is
+// true. The session is lost when the query succeeds nonetheless.
+func (c *MapiConn) trackSession(query string) (unsaved bool, err error) {
+	restorable := false
+	for _, stmt := range splitStatements(query) {
+		if sessionProcedure.MatchString(stmt) {
+			c.session.lost = true
+			return false, nil
+		}
+		if sessionStatement.MatchString(stmt) {
+			restorable = true
+		}
+	}
+	if !restorable {
+		return false, nil
+	}
+	if !c.session.saved {
+		user, role, schema, err := c.sessionSettings()
+		if err != nil {
+			if !c.IsConnected() {
+				return false, err
+			}
+			return true, nil
+		}
+		c.session.user = user
+		c.session.role = role
+		c.session.schema = schema
+		c.session.saved = true
+	}
+	c.session.changed = true
+	return false, nil
+}
+
+// sessionSettings returns the current user, role and schema of the session
+func (c *MapiConn) sessionSettings() (string, string, string, error) {
+	r, err := c.execute("SELECT CURRENT_USER, CURRENT_ROLE, CURRENT_SCHEMA")
+	if err != nil {
+		return "", "", "", err
+	}
+	var s ResultSet
+	if err := s.StoreResult(r); err != nil {
+		return "", "", "", err
+	}
+	if len(s.Rows) != 1 || len(s.Rows[0]) != 3 {
+		return "", "", "", fmt.Errorf("mapi: session settings are not available")
+	}
+	var settings [3]string
+	for i, v := range s.Rows[0] {
+		str, ok := v.(string)
+		if !ok {
+			return "", "", "", fmt.Errorf("mapi: session settings are not available")
+		}
+		settings[i] = str
+	}
+	return settings[0], settings[1], settings[2], nil
+}
+
+// ResetSession prepares the connection for the next user. An open transaction,
+// or the work that was not committed without auto commit, is rolled back, and the settings that were changed by statements like
+// SET SCHEMA and SET ROLE are restored, as well as the auto commit mode of
+// the configuration. The time zone is restored when it was configured, with
+// its current offset. An error wrapping ErrBadConn is returned when the session
+// cannot be restored, the connection should then not be used anymore.
+func (c *MapiConn) ResetSession() error {
+	if c.State != mapi_STATE_READY {
+		return fmt.Errorf("mapi: database is not connected: %w", ErrBadConn)
+	}
+
+	// Without auto commit, statements run in a transaction that the server
+	// does not report. It is rolled back before auto commit is restored,
+	// which would commit it.
+	if c.inTransaction || !c.autoCommit {
+		if _, err := c.execute("ROLLBACK"); err != nil {
+			return fmt.Errorf("mapi: cannot rollback the transaction: %v: %w", err, ErrBadConn)
+		}
+		c.inTransaction = false
+	}
+	if c.session.lost {
+		return fmt.Errorf("mapi: session settings cannot be restored: %w", ErrBadConn)
+	}
+	if c.autoCommit != c.config.AutoCommit {
+		if _, err := c.SetAutoCommit(c.config.AutoCommit); err != nil {
+			return fmt.Errorf("mapi: cannot restore auto commit: %v: %w", err, ErrBadConn)
+		}
+	}
+	if !c.session.changed {
+		if c.zoneChanged() {
+			if err := c.SetTimezone(c.timezone); err != nil {
+				return fmt.Errorf("mapi: cannot set the time zone: %v: %w", err, ErrBadConn)
+			}
+		}
+		return nil
+	}
+
+	if err := c.restoreSession(); err != nil {
+		return fmt.Errorf("mapi: cannot restore the session settings: %v: %w", err, ErrBadConn)
+	}
+	c.session.changed = false
+	return nil
+}
+
+// restoreSession sets the saved user, role and schema, and the time zone
+// from the configuration
+func (c *MapiConn) restoreSession() error {
+	user, role, schema, err := c.sessionSettings()
+	if err != nil {
+		return err
+	}
+	if user != c.session.user {
+		if _, err := c.execute(fmt.Sprintf("SET SESSION AUTHORIZATION %s", quoteIdentifier(c.session.user))); err != nil {
+			return err
+		}
+		// The role and schema depend on the user
+		role = ""
+		schema = ""
+	}
+	if role != c.session.role {
+		if _, err := c.execute(fmt.Sprintf("SET ROLE %s", quoteIdentifier(c.session.role))); err != nil {
+			return err
+		}
+	}
+	if schema != c.session.schema {
+		if _, err := c.execute(fmt.Sprintf("SET SCHEMA %s", quoteIdentifier(c.session.schema))); err != nil {
+			return err
+		}
+	}
+	if c.timezone != nil {
+		if err := c.SetTimezone(c.timezone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
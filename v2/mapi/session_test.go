@@ -0,0 +1,272 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+const sessionSettingsResponse = `&1 0 1 3 1 0 0 0 0
+% .%1,	.%2,	.%3 # table_name
+% %1,	%2,	%3 # name
+% varchar,	varchar,	varchar # type
+% 7,	7,	3 # length
+% 0 0,	0 0,	0 0 # typesizes
+[ "monetdb",	"monetdb",	"sys"	]
+`
+
+// recordCommands answers the commands that the client sends like serveBlocks,
+// and returns them on the channel
+func recordCommands(server net.Conn, commands chan<- string, responses ...string) {
+	defer close(commands)
+	c := MapiConn{State: mapi_STATE_READY, conn: server}
+	for _, response := range responses {
+		cmd, err := c.getBlock()
+		if err != nil {
+			return
+		}
+		commands <- string(cmd)
+		if err := c.putBlock([]byte(response)); err != nil {
+			return
+		}
+	}
+}
+
+func TestTrackTransaction(t *testing.T) {
+	tcs := []struct {
+		response string
+		expected bool
+	}{
+		{"&4 f\n", true},
+		{"&4 t\n", false},
+		{"&2 1 -1\n", false},
+		{"&4 f\n&2 1 -1\n", true},
+		{"&4 f\n&2 1 -1\n&4 t\n", false},
+	}
+	for _, tc := range tcs {
+		var c MapiConn
+		c.trackTransaction(tc.response)
+		if c.InTransaction() != tc.expected {
+			t.Errorf("Unexpected transaction state for %q", tc.response)
+		}
+	}
+}
+
+func TestTrackSession(t *testing.T) {
+	tcs := []struct {
+		query   string
+		changed bool
+		lost    bool
+	}{
+		{"SET SCHEMA other", true, false},
+		{"  set local time zone interval '+01:00' hour to minute", true, false},
+		{"/* comment */ SET ROLE admin", true, false},
+		{"SELECT 1; SET SESSION AUTHORIZATION other", true, false},
+		{"UPDATE t SET role = 'x'", false, false},
+		{"UPDATE t SET optimizer = 'x'", false, false},
+		{"SELECT 'SET SCHEMA other'", false, false},
+		{"SELECT 'a;SET SCHEMA other'", false, false},
+		{"SELECT 'sys.setquerytimeout(1)'", false, false},
+		{"SELECT 1 -- SET SCHEMA other", false, false},
+		{"SELECT 1 /* ; CALL sys.setsessiontimeout(1) */", false, false},
+		{`SELECT "set role" FROM t`, false, false},
+		{"SET OPTIMIZER = 'minimal_pipe'", false, true},
+		{"CALL sys.setsessiontimeout(10)", false, true},
+		{`call "sys"."setquerytimeout"(10)`, false, true},
+		{"SELECT 1; CALL setmemorylimit(100)", false, true},
+	}
+	for _, tc := range tcs {
+		server, client := net.Pipe()
+		commands := make(chan string, 2)
+		go recordCommands(server, commands, sessionSettingsResponse)
+		c := MapiConn{State: mapi_STATE_READY, conn: client}
+
+		if _, err := c.trackSession(tc.query); err != nil {
+			t.Errorf("Unexpected error for %q: %v", tc.query, err)
+		}
+		if c.session.changed != tc.changed || c.session.lost != tc.lost {
+			t.Errorf("Unexpected session state for %q: changed %t, lost %t", tc.query, c.session.changed, c.session.lost)
+		}
+		client.Close()
+		server.Close()
+	}
+}
+
+func TestResetSession(t *testing.T) {
+	t.Run("Verify an unchanged session is not touched", func(t *testing.T) {
+		c := MapiConn{State: mapi_STATE_READY, autoCommit: true, config: Config{AutoCommit: true}}
+		if err := c.ResetSession(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Verify an open transaction is rolled back", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		commands := make(chan string, 4)
+		go recordCommands(server, commands, "&4 f\n", "&4 t\n")
+		c := MapiConn{State: mapi_STATE_READY, conn: client}
+
+		if _, err := c.Execute("START TRANSACTION"); err != nil {
+			t.Fatal(err)
+		}
+		if !c.InTransaction() {
+			t.Fatal("Transaction should be open")
+		}
+		if err := c.ResetSession(); err != nil {
+			t.Error(err)
+		}
+		client.Close()
+		var received []string
+		for cmd := range commands {
+			received = append(received, cmd)
+		}
+		if len(received) != 2 || received[1] != "sROLLBACK;" {
+			t.Errorf("Unexpected commands: %q", received)
+		}
+		if c.InTransaction() {
+			t.Error("Transaction should be closed")
+		}
+	})
+
+	t.Run("Verify the schema is restored", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		commands := make(chan string, 8)
+		changed := `&1 0 1 3 1 0 0 0 0
+% .%1,	.%2,	.%3 # table_name
+% %1,	%2,	%3 # name
+% varchar,	varchar,	varchar # type
+% 7,	7,	5 # length
+% 0 0,	0 0,	0 0 # typesizes
+[ "monetdb",	"monetdb",	"other"	]
+`
+		go recordCommands(server, commands, sessionSettingsResponse, "&3\n", changed, "&3\n")
+		c := MapiConn{State: mapi_STATE_READY, autoCommit: true, config: Config{AutoCommit: true}, conn: client}
+
+		if _, err := c.Execute("SET SCHEMA other"); err != nil {
+			t.Fatal(err)
+		}
+		if !c.SessionChanged() {
+			t.Fatal("Session should be changed")
+		}
+		if err := c.ResetSession(); err != nil {
+			t.Error(err)
+		}
+		client.Close()
+		var received []string
+		for cmd := range commands {
+			received = append(received, cmd)
+		}
+		if len(received) != 4 || received[3] != `sSET SCHEMA "sys";` {
+			t.Errorf("Unexpected commands: %q", received)
+		}
+		if c.SessionChanged() {
+			t.Error("Session should be restored")
+		}
+	})
+
+	t.Run("Verify the time zone gets its current offset", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		commands := make(chan string, 2)
+		go recordCommands(server, commands, "&3\n")
+		// The session has the offset from before a change to daylight saving time
+		c := MapiConn{State: mapi_STATE_READY, autoCommit: true, config: Config{AutoCommit: true}, conn: client, timezone: time.UTC, zone: time.FixedZone("", 3600)}
+
+		if err := c.ResetSession(); err != nil {
+			t.Error(err)
+		}
+		client.Close()
+		if cmd := <-commands; cmd != "sSET TIME ZONE INTERVAL '+00:00' HOUR TO MINUTE;" {
+			t.Errorf("Unexpected command: %q", cmd)
+		}
+		if _, offset := (time.Time{}).In(c.zone).Zone(); offset != 0 {
+			t.Errorf("Unexpected offset %d", offset)
+		}
+	})
+
+	t.Run("Verify a failed transaction does not lose the session", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		commands := make(chan string, 4)
+		go recordCommands(server, commands,
+			"!25005!current transaction is aborted\n",
+			"!25005!current transaction is aborted\n",
+			"&4 t\n")
+		c := MapiConn{State: mapi_STATE_READY, conn: client, inTransaction: true}
+
+		if _, err := c.Execute("SET SCHEMA other"); err == nil {
+			t.Fatal("Expected an error in the failed transaction")
+		}
+		if err := c.ResetSession(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Verify work without auto commit is rolled back", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		commands := make(chan string, 4)
+		go recordCommands(server, commands, "&2 1 -1\n", "&3\n")
+		c := MapiConn{State: mapi_STATE_READY, conn: client, autoCommit: false, config: Config{AutoCommit: false}}
+
+		if _, err := c.Execute("INSERT INTO t VALUES (1)"); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.ResetSession(); err != nil {
+			t.Error(err)
+		}
+		client.Close()
+		var received []string
+		for cmd := range commands {
+			received = append(received, cmd)
+		}
+		if len(received) != 2 || received[1] != "sROLLBACK;" {
+			t.Errorf("Unexpected commands: %q", received)
+		}
+	})
+
+	t.Run("Verify auto commit is restored after a rollback", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		commands := make(chan string, 4)
+		go recordCommands(server, commands, "&3\n", "")
+		c := MapiConn{State: mapi_STATE_READY, conn: client, autoCommit: false, config: Config{AutoCommit: true}}
+
+		if err := c.ResetSession(); err != nil {
+			t.Error(err)
+		}
+		client.Close()
+		var received []string
+		for cmd := range commands {
+			received = append(received, cmd)
+		}
+		if len(received) != 2 || received[0] != "sROLLBACK;" || received[1] != "Xauto_commit 1" {
+			t.Errorf("Unexpected commands: %q", received)
+		}
+		if !c.autoCommit {
+			t.Error("Auto commit should be enabled")
+		}
+	})
+
+	t.Run("Verify a session that cannot be restored", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		commands := make(chan string, 2)
+		go recordCommands(server, commands, "")
+		c := MapiConn{State: mapi_STATE_READY, autoCommit: true, config: Config{AutoCommit: true}, conn: client}
+
+		if _, err := c.Execute("CALL sys.setsessiontimeout(10)"); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.ResetSession(); !errors.Is(err, ErrBadConn) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
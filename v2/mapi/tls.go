@@ -0,0 +1,105 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// The protocol name that the client announces during the TLS handshake
+const mapi_TLS_PROTOCOL = "mapi/9"
+
+// newTLSConfig creates the TLS configuration for a connection.
+//
+// The server certificate is verified against the certificates in the Cert
+// file, or against the system certificate pool when no file is given. When
+// CertHash is set, the certificate is only checked against the hash. A client
+// certificate is sent when ClientKey is set. The certificate is read from the
+// ClientCert file, or from the ClientKey file when ClientCert is empty.
+func newTLSConfig(c Config) (*tls.Config, error) {
+	serverName := c.ServerName
+	if serverName == "" {
+		serverName = strings.Trim(c.Hostname, "[]")
+	}
+
+	conf := &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS13,
+		NextProtos: []string{mapi_TLS_PROTOCOL},
+	}
+
+	if c.CertHash != "" {
+		hash, err := parseCertHash(c.CertHash)
+		if err != nil {
+			return nil, err
+		}
+		// The normal verification is replaced by a check of the certificate hash
+		conf.InsecureSkipVerify = true
+		conf.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyCertHash(rawCerts, hash)
+		}
+	} else if c.Cert != "" {
+		pem, err := os.ReadFile(c.Cert)
+		if err != nil {
+			return nil, fmt.Errorf("mapi: cannot read certificate file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("mapi: no certificates found in %s", c.Cert)
+		}
+		conf.RootCAs = pool
+	}
+
+	if c.ClientKey != "" {
+		certFile := c.ClientCert
+		if certFile == "" {
+			certFile = c.ClientKey
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("mapi: cannot load client certificate: %v", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	} else if c.ClientCert != "" {
+		return nil, fmt.Errorf("mapi: client certificate given without client key")
+	}
+
+	return conf, nil
+}
+
+// parseCertHash parses a certificate hash in the form "sha256:hexdigits". The
+// digits may be separated by colons, and may be a prefix of the full hash.
+func parseCertHash(certHash string) ([]byte, error) {
+	algo, digits, found := Cut(certHash, ":")
+	if !found || strings.ToLower(algo) != "sha256" {
+		return nil, fmt.Errorf("mapi: unsupported certificate hash: %s", certHash)
+	}
+
+	digits = strings.ToLower(strings.ReplaceAll(digits, ":", ""))
+	if digits == "" || len(digits) > 2*sha256.Size || strings.Trim(digits, "0123456789abcdef") != "" {
+		return nil, fmt.Errorf("mapi: invalid certificate hash: %s", certHash)
+	}
+	return []byte(digits), nil
+}
+
+// verifyCertHash checks that the hash of the server certificate starts with
+// the expected hexadecimal digits
+func verifyCertHash(rawCerts [][]byte, digits []byte) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("mapi: server did not send a certificate")
+	}
+	sum := sha256.Sum256(rawCerts[0])
+	if !bytes.HasPrefix([]byte(hex.EncodeToString(sum[:])), digits) {
+		return fmt.Errorf("mapi: server certificate does not match the certificate hash")
+	}
+	return nil
+}
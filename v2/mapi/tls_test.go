@@ -0,0 +1,58 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestCertHash(t *testing.T) {
+	cert := []byte("not really a certificate")
+	sum := sha256.Sum256(cert)
+	digits := hex.EncodeToString(sum[:])
+
+	t.Run("Verify certificate hash with the full hash", func(t *testing.T) {
+		hash, err := parseCertHash("sha256:" + digits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verifyCertHash([][]byte{cert}, hash); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Verify certificate hash with a prefix and colons", func(t *testing.T) {
+		hash, err := parseCertHash("SHA256:" + digits[0:2] + ":" + digits[2:4] + ":" + digits[4:5])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verifyCertHash([][]byte{cert}, hash); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Verify certificate hash mismatch", func(t *testing.T) {
+		hash, err := parseCertHash("sha256:" + digits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verifyCertHash([][]byte{[]byte("other certificate")}, hash); err == nil {
+			t.Error("Expected an error for a different certificate")
+		}
+		if err := verifyCertHash(nil, hash); err == nil {
+			t.Error("Expected an error when there is no certificate")
+		}
+	})
+
+	t.Run("Verify invalid certificate hashes", func(t *testing.T) {
+		for _, h := range []string{"", "sha256:", "sha1:0123", "sha256:0g", "0123", "sha256:" + digits + "00"} {
+			if _, err := parseCertHash(h); err == nil {
+				t.Errorf("Expected an error for certificate hash: %s", h)
+			}
+		}
+	})
+}
@@ -9,9 +9,13 @@ import (
 	"time"
 )
 
-// Time represents MonetDB's Time datatype.
+// Time represents MonetDB's Time datatype. The server keeps the time with
+// microsecond precision, Nsec holds the fraction of the second in nanoseconds.
+// Nsec was added after Hour, Min and Sec, a composite literal without field
+// names, like Time{10, 20, 30}, no longer compiles; name the fields instead.
 type Time struct {
 	Hour, Min, Sec int
+	Nsec           int
 }
 
 // Time represents MonetDB's Date datatype.
@@ -21,15 +25,16 @@ type Date struct {
 	Day   int
 }
 
-// String returns a string representation of a Time
-// in the form "HH:YY:MM".
+// String returns a string representation of a Time in the form "HH:MM:SS",
+// followed by the fraction of the second if any. The fraction is rounded to
+// microseconds, the precision of the server.
 func (t Time) String() string {
-	return fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Min, t.Sec)
+	return t.Time().Round(time.Microsecond).Format("15:04:05.999999")
 }
 
 // Time converts to time.Time. The date is set to January 1, 1970.
 func (t Time) Time() time.Time {
-	return time.Date(1970, time.January, 1, t.Hour, t.Min, t.Sec, 0, time.UTC)
+	return time.Date(1970, time.January, 1, t.Hour, t.Min, t.Sec, t.Nsec, time.UTC)
 }
 
 // String returns a string representation of a Date
@@ -46,7 +51,7 @@ func (d Date) Time() time.Time {
 // GetTime takes the clock part of a time.Time and put it in a Time
 func GetTime(t time.Time) Time {
 	hour, min, sec := t.Clock()
-	return Time{hour, min, sec}
+	return Time{hour, min, sec, t.Nanosecond()}
 }
 
 // GetDate takes the date part of a time.Time and put it in a Date
@@ -17,7 +17,7 @@ func TestTimeToTime(t *testing.T) {
 	month := time.January
 	day := 1
 
-	v := Time{hour, minute, second}
+	v := Time{hour, minute, second, 0}
 	time := v.Time()
 
 	if time.Hour() != hour {
@@ -0,0 +1,52 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+)
+
+// URL represents MonetDB's url datatype.
+type URL struct {
+	url.URL
+}
+
+// Scan implements the sql.Scanner interface. Url columns are returned as
+// text.
+func (u *URL) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case URL:
+		*u = v
+		return nil
+	case *url.URL:
+		if v == nil {
+			return fmt.Errorf("mapi: cannot scan NULL into URL")
+		}
+		u.URL = *v
+		return nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case nil:
+		return fmt.Errorf("mapi: cannot scan NULL into URL")
+	default:
+		return fmt.Errorf("mapi: cannot scan %T into URL", src)
+	}
+	parsed, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("mapi: invalid url: %q", s)
+	}
+	u.URL = *parsed
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+func (u URL) Value() (driver.Value, error) {
+	return u.URL.String(), nil
+}
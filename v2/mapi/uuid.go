@@ -0,0 +1,75 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// UUID represents MonetDB's uuid datatype.
+type UUID [16]byte
+
+// ParseUUID parses a UUID in the form "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx".
+// The dashes are optional.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	h := strings.ReplaceAll(strings.TrimSpace(s), "-", "")
+	if len(h) != 2*len(u) {
+		return u, fmt.Errorf("mapi: invalid uuid: %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(h)); err != nil {
+		return u, fmt.Errorf("mapi: invalid uuid: %q", s)
+	}
+	return u, nil
+}
+
+// String returns the UUID in the form "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx".
+func (u UUID) String() string {
+	h := hex.EncodeToString(u[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// Scan implements the sql.Scanner interface. Uuid columns are returned as
+// text, a []byte is only taken as the raw value when it has 16 bytes that are
+// not text.
+func (u *UUID) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case UUID:
+		*u = v
+	case string:
+		*u, err = ParseUUID(v)
+	case []byte:
+		// Sixteen bytes that are not text are the raw value of the UUID
+		if len(v) == len(u) && !isText(v) {
+			copy(u[:], v)
+		} else {
+			*u, err = ParseUUID(string(v))
+		}
+	case nil:
+		err = fmt.Errorf("mapi: cannot scan NULL into UUID")
+	default:
+		err = fmt.Errorf("mapi: cannot scan %T into UUID", src)
+	}
+	return err
+}
+
+// isText reports whether the bytes are printable ASCII
+func isText(b []byte) bool {
+	for _, c := range b {
+		if c < ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Value implements the driver.Valuer interface.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
@@ -0,0 +1,55 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package mapi
+
+import (
+	"testing"
+)
+
+func TestUUID(t *testing.T) {
+	const s = "6c49869d-45dc-4b3f-9d6d-2a3e47ba3e0a"
+
+	t.Run("Verify parsing and formatting", func(t *testing.T) {
+		for _, v := range []string{s, "6C49869D45DC4B3F9D6D2A3E47BA3E0A"} {
+			u, err := ParseUUID(v)
+			if err != nil {
+				t.Errorf("Error parsing %s: %v", v, err)
+			} else if u.String() != s {
+				t.Errorf("Invalid uuid: %s, expected: %s", u, s)
+			}
+		}
+	})
+
+	t.Run("Verify invalid uuids", func(t *testing.T) {
+		for _, v := range []string{"", "6c49869d", s + "00", "xc49869d-45dc-4b3f-9d6d-2a3e47ba3e0a"} {
+			if _, err := ParseUUID(v); err == nil {
+				t.Errorf("Parsing %q did not fail", v)
+			}
+		}
+	})
+
+	t.Run("Verify scanning", func(t *testing.T) {
+		expected, _ := ParseUUID(s)
+		for _, src := range []interface{}{s, []byte(s), expected[:], expected} {
+			var u UUID
+			if err := u.Scan(src); err != nil {
+				t.Error(err)
+			} else if u != expected {
+				t.Errorf("Invalid scanned value: %s", u)
+			}
+		}
+		var u UUID
+		if err := u.Scan(nil); err == nil {
+			t.Error("Scanning NULL did not fail")
+		}
+	})
+
+	t.Run("Verify scanning text of sixteen bytes", func(t *testing.T) {
+		var u UUID
+		if err := u.Scan([]byte("6c49869d45dc4b3f")); err == nil {
+			t.Errorf("Scanning text did not fail: %s", u)
+		}
+	})
+}
@@ -5,8 +5,11 @@
 package monetdb
 
 import (
+	"context"
 	"database/sql"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParamIntegration(t *testing.T) {
@@ -339,3 +342,220 @@ func TestMultipleParamIntegration(t *testing.T) {
 		}
 	})
 }
+
+func TestTypedParamIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run("Exec create table", func(t *testing.T) {
+		_, err := db.Exec("create table test_typed ( id int, amount decimal(10,2), name varchar(4), day date)")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Arguments are converted to the parameter types", func(t *testing.T) {
+		stmt, err := db.Prepare("insert into test_typed values ( ?, ?, ?, ? )")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stmt.Close()
+		day := time.Date(2024, time.March, 1, 23, 30, 0, 0, time.UTC)
+		if _, err := stmt.Exec("1", 12.345, 42, day); err != nil {
+			t.Fatal(err)
+		}
+		var amount, name, date string
+		err = db.QueryRow("select cast(amount as varchar(20)), name, cast(day as varchar(20)) from test_typed").Scan(&amount, &name, &date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amount != "12.35" || name != "42" || date != "2024-03-01" {
+			t.Errorf("Unexpected values %s, %s, %s", amount, name, date)
+		}
+	})
+
+	t.Run("Invalid arguments are reported", func(t *testing.T) {
+		stmt, err := db.Prepare("insert into test_typed values ( ?, ?, ?, ? )")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stmt.Close()
+		_, err = stmt.Exec(1, 1, "too long", nil)
+		if err == nil || !strings.Contains(err.Error(), "parameter 3") {
+			t.Errorf("Unexpected error %v", err)
+		}
+	})
+
+	t.Run("Exec drop table", func(t *testing.T) {
+		if _, err := db.Exec("drop table test_typed"); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestPositionalParamIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run("Query with positional arguments", func(t *testing.T) {
+		var s string
+		var i int
+		err := db.QueryRow("select 'why?' || ?, ? + 1", "yes", 41).Scan(&s, &i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != "why?yes" || i != 42 {
+			t.Errorf("Unexpected values %s, %d", s, i)
+		}
+	})
+
+	t.Run("Wrong number of arguments", func(t *testing.T) {
+		if _, err := db.Exec("select ?, ?", 1); err == nil {
+			t.Error("Query with too few arguments did not fail")
+		}
+	})
+}
+
+func TestTimeParamIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	// The session uses UTC+01:00
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb?timezone=60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run("Pass the current time", func(t *testing.T) {
+		now := time.Now()
+		var ts time.Time
+		if err := db.QueryRow("select ?", now).Scan(&ts); err != nil {
+			t.Fatal(err)
+		}
+		if !ts.Equal(now.Round(time.Microsecond)) {
+			t.Errorf("Unexpected time %v, expected %v", ts, now)
+		}
+	})
+
+	t.Run("Pass local times as wall clock times", func(t *testing.T) {
+		ctx := context.Background()
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		err = conn.Raw(func(driverConn interface{}) error {
+			driverConn.(*Conn).SetLocalTimePolicy(LocalTimeWallClock)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		local := time.Date(2001, time.January, 2, 10, 20, 30, 0, time.Local)
+		var s string
+		if err := conn.QueryRowContext(ctx, "select cast(cast(? as timestamp) as varchar(32))", local).Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		if s != "2001-01-02 10:20:30.000000" {
+			t.Errorf("Unexpected timestamp %s", s)
+		}
+	})
+
+	t.Run("Pass a time in another zone to a prepared timestamp", func(t *testing.T) {
+		if _, err := db.Exec("create table test_time ( ts timestamp )"); err != nil {
+			t.Fatal(err)
+		}
+		defer db.Exec("drop table test_time")
+		stmt, err := db.Prepare("insert into test_time values ( ? )")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stmt.Close()
+		// 10:20:30 at UTC-05:00 is 16:20:30 in the session
+		ts := time.Date(2001, time.January, 2, 10, 20, 30, 999999600, time.FixedZone("", -5*3600))
+		if _, err := stmt.Exec(ts); err != nil {
+			t.Fatal(err)
+		}
+		var s string
+		if err := db.QueryRow("select cast(ts as varchar(32)) from test_time").Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		if s != "2001-01-02 16:20:31.000000" {
+			t.Errorf("Unexpected timestamp %s", s)
+		}
+	})
+}
+
+type testUserID int64
+
+func TestValuerParamIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run("Exec create table", func(t *testing.T) {
+		if _, err := db.Exec("create table test_valuer ( id bigint, name varchar(16) )"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Insert named types, pointers and null types", func(t *testing.T) {
+		stmt, err := db.Prepare("insert into test_valuer values ( ?, ? )")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stmt.Close()
+		name := "first"
+		if _, err := stmt.Exec(testUserID(1), &name); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stmt.Exec(sql.NullInt64{Int64: 2, Valid: true}, sql.NullString{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Query with named types", func(t *testing.T) {
+		var name sql.NullString
+		if err := db.QueryRow("select name from test_valuer where id = ?", testUserID(2)).Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		if name.Valid {
+			t.Errorf("Unexpected name %s", name.String)
+		}
+		var id testUserID
+		if err := db.QueryRow("select id from test_valuer where name = ?", "first").Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		if id != 1 {
+			t.Errorf("Unexpected id %d", id)
+		}
+	})
+
+	t.Run("Exec drop table", func(t *testing.T) {
+		if _, err := db.Exec("drop table test_valuer"); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -7,9 +7,12 @@ package monetdb
 import (
 	"context"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"math/big"
+	"net/url"
 	"reflect"
 	"strings"
 	"time"
@@ -18,6 +21,7 @@ import (
 )
 
 type Rows struct {
+	ctx       context.Context
 	conn      *mapi.MapiConn
 	resultset *mapi.ResultSet
 	active    bool
@@ -31,10 +35,18 @@ type Rows struct {
 	rows      [][]driver.Value
 	schema    []mapi.TableElement
 	columns   []string
+	// The server still holds rows of the current result
+	held bool
+
+	// The results of the next statements, when the query had several
+	results []*mapi.ResultSet
+	// The error of a statement after these results, returned by NextResultSet
+	resultsErr error
 }
 
-func newRows(c *mapi.MapiConn, r *mapi.ResultSet) *Rows {
+func newRows(ctx context.Context, c *mapi.MapiConn, r *mapi.ResultSet) *Rows {
 	return &Rows{
+		ctx:       ctx,
 		conn:      c,
 		resultset: r,
 		active:    true,
@@ -45,6 +57,87 @@ func newRows(c *mapi.MapiConn, r *mapi.ResultSet) *Rows {
 	}
 }
 
+// setResults stores the results of the statements in the query. Only the results of
+// statements that return a table are result sets, the first one becomes the current one.
+// When there is none, the rows are empty.
+func (r *Rows) setResults(results []*mapi.ResultSet) {
+	r.results = nil
+	for _, rs := range results {
+		if rs.Metadata.ColumnCount > 0 {
+			r.results = append(r.results, rs)
+		}
+	}
+	if len(r.results) == 0 {
+		r.results = append(r.results, &mapi.ResultSet{})
+	}
+	r.nextResult()
+}
+
+// nextResult makes the next result set the current one
+func (r *Rows) nextResult() {
+	rs := r.results[0]
+	r.results = r.results[1:]
+
+	r.resultset = rs
+	r.queryId = rs.Metadata.QueryId
+	r.lastRowId = rs.Metadata.LastRowId
+	r.rowCount = rs.Metadata.RowCount
+	r.offset = rs.Metadata.Offset
+	r.rows = convertRows(rs.Rows, rs.Metadata.ColumnCount)
+	r.schema = rs.Schema
+	r.columns = nil
+	r.rowNum = 0
+	r.held = resultHeld(rs)
+}
+
+// resultHeld reports whether the server holds rows of the result that were not sent
+// with the response to the query
+func resultHeld(rs *mapi.ResultSet) bool {
+	return rs.Metadata.ColumnCount > 0 && rs.Metadata.RowCount > len(rs.Rows)
+}
+
+// closeResult releases the current result on the server, when it holds rows of it
+func (r *Rows) closeResult() error {
+	if !r.held {
+		return nil
+	}
+	r.held = false
+	return r.release(r.queryId)
+}
+
+// release releases a result on the server. On a broken connection the results are
+// already gone.
+func (r *Rows) release(queryId int) error {
+	if r.conn == nil || !r.conn.IsConnected() {
+		return nil
+	}
+	return checkBadConn(r.conn.CloseResult(queryId))
+}
+
+// HasNextResultSet reports whether the query had another statement that returned a table,
+// or that failed.
+func (r *Rows) HasNextResultSet() bool {
+	return r.active && (len(r.results) > 0 || r.resultsErr != nil)
+}
+
+// NextResultSet advances to the result of the next statement that returned a table.
+func (r *Rows) NextResultSet() error {
+	if !r.active {
+		return fmt.Errorf("monetdb: rows closed")
+	}
+	if len(r.results) == 0 && r.resultsErr == nil {
+		return io.EOF
+	}
+	if err := r.closeResult(); err != nil {
+		return err
+	}
+	if len(r.results) == 0 {
+		return r.resultsErr
+	}
+	r.nextResult()
+	return nil
+}
+
 func (r *Rows) Columns() []string {
 	if r.columns == nil {
 		r.columns = make([]string, len(r.schema))
@@ -55,9 +148,24 @@ func (r *Rows) Columns() []string {
 	return r.columns
 }
 
+// Close releases the results that the server still holds, of the current result set
+// and of the result sets that were not read.
 func (r *Rows) Close() error {
+	if !r.active {
+		return nil
+	}
 	r.active = false
-	return nil
+	err := r.closeResult()
+	for _, rs := range r.results {
+		if !resultHeld(rs) {
+			continue
+		}
+		if releaseErr := r.release(rs.Metadata.QueryId); err == nil {
+			err = releaseErr
+		}
+	}
+	r.results = nil
+	return err
 }
 
 func (r *Rows) Next(dest []driver.Value) error {
@@ -69,6 +177,10 @@ func (r *Rows) Next(dest []driver.Value) error {
 	}
 
 	if r.rowNum >= r.rowCount {
+		// All rows are read, the server does not need to keep them
+		if err := r.closeResult(); err != nil {
+			return err
+		}
 		return io.EOF
 	}
 
@@ -80,9 +192,29 @@ func (r *Rows) Next(dest []driver.Value) error {
 	}
 
 	for i, v := range r.rows[r.rowNum-r.offset] {
-		if vv, ok := v.(string); ok {
+		switch vv := v.(type) {
+		case string:
+			dest[i] = []byte(vv)
+		case mapi.Decimal:
+			// As text, a decimal can be scanned into a Decimal, a string or a float
+			dest[i] = []byte(vv.String())
+		case *big.Int:
+			// As text, a hugeint can be scanned into a HugeInt, a string or an int64
+			dest[i] = []byte(vv.String())
+		case mapi.UUID:
+			dest[i] = []byte(vv.String())
+		case json.RawMessage:
 			dest[i] = []byte(vv)
-		} else {
+		case mapi.Inet:
+			dest[i] = []byte(vv.String())
+		case *url.URL:
+			dest[i] = []byte(vv.String())
+		case time.Duration:
+			// Durations are scanned as the number of nanoseconds
+			dest[i] = int64(vv)
+		case mapi.MonthInterval:
+			dest[i] = int64(vv)
+		default:
 			dest[i] = v
 		}
 	}
@@ -106,9 +238,14 @@ func (s *Rows) mapiDo(ctx context.Context, amount int) (string, error) {
 		resultstring string
 		err          error
 	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	c := make(chan res, 1)
+	done := make(chan struct{})
 
 	go func() {
+		defer close(done)
 		r, err := s.conn.FetchNext(s.queryId, s.offset, amount)
 		result := res{r, err}
 		c <- result
@@ -116,7 +253,8 @@ func (s *Rows) mapiDo(ctx context.Context, amount int) (string, error) {
 
 	select {
 	case <-ctx.Done():
-		<-c // Wait for the goroutine to return. Later we need to cancel the query on the database
+		// No query runs on the server during a fetch, there is nothing to stop
+		cancelQuery(s.conn, -1, done)
 		return "", ctx.Err()
 	case result := <-c:
 		return result.resultstring, result.err
@@ -129,10 +267,14 @@ func (r *Rows) fetchNext() error {
 	}
 
 	r.offset += len(r.rows)
-	end := min(r.rowCount, r.rowNum+mapi.MAPI_ARRAY_SIZE)
+	size := r.conn.ReplySize()
+	if size <= 0 {
+		size = mapi.MAPI_ARRAY_SIZE
+	}
+	end := min(r.rowCount, r.rowNum+size)
 	amount := end - r.offset
 
-	res, err := r.mapiDo(context.Background(), amount)
+	res, err := r.mapiDo(r.ctx, amount)
 	if err != nil {
 		return err
 	}
@@ -183,14 +325,22 @@ func (r *Rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok b
 func (r *Rows) ColumnTypeScanType(index int) reflect.Type {
 	var scantype reflect.Type
 
+	// The type of the values of a registered decoder is not known in advance
+	if _, ok := r.resultset.Converters.Decoder(r.schema[index].ColumnType); ok {
+		return reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+
 	switch r.schema[index].ColumnType {
 	case mapi.MDB_VARCHAR,
 		mapi.MDB_CHAR,
 		mapi.MDB_CLOB,
-		mapi.MDB_INTERVAL,
-		mapi.MDB_MONTH_INTERVAL,
-		mapi.MDB_SEC_INTERVAL:
+		mapi.MDB_INTERVAL:
 		scantype = reflect.TypeOf("")
+	case mapi.MDB_SEC_INTERVAL,
+		mapi.MDB_DAY_INTERVAL:
+		scantype = reflect.TypeOf(time.Duration(0))
+	case mapi.MDB_MONTH_INTERVAL:
+		scantype = reflect.TypeOf(mapi.MonthInterval(0))
 	case mapi.MDB_NULL:
 		scantype = reflect.TypeOf(nil)
 	case mapi.MDB_BLOB:
@@ -199,8 +349,9 @@ func (r *Rows) ColumnTypeScanType(index int) reflect.Type {
 		scantype = reflect.TypeOf(true)
 	case mapi.MDB_REAL:
 		scantype = reflect.TypeOf(float32(0))
-	case mapi.MDB_DECIMAL,
-		mapi.MDB_DOUBLE,
+	case mapi.MDB_DECIMAL:
+		scantype = reflect.TypeOf(mapi.Decimal{})
+	case mapi.MDB_DOUBLE,
 		mapi.MDB_FLOAT:
 		scantype = reflect.TypeOf(float64(0))
 	case mapi.MDB_TINYINT:
@@ -212,13 +363,23 @@ func (r *Rows) ColumnTypeScanType(index int) reflect.Type {
 		mapi.MDB_MEDIUMINT,
 		mapi.MDB_WRD:
 		scantype = reflect.TypeOf(int32(0))
+	case mapi.MDB_HUGEINT:
+		scantype = reflect.TypeOf(mapi.HugeInt{})
+	case mapi.MDB_UUID:
+		scantype = reflect.TypeOf(mapi.UUID{})
+	case mapi.MDB_JSON:
+		scantype = reflect.TypeOf(json.RawMessage{})
+	case mapi.MDB_INET:
+		scantype = reflect.TypeOf(mapi.Inet{})
+	case mapi.MDB_URL:
+		scantype = reflect.TypeOf(mapi.URL{})
 	case mapi.MDB_BIGINT,
-		mapi.MDB_HUGEINT,
 		mapi.MDB_SERIAL,
 		mapi.MDB_LONGINT:
 		scantype = reflect.TypeOf(int64(0))
 	case mapi.MDB_DATE,
 		mapi.MDB_TIME,
+		mapi.MDB_TIMETZ,
 		mapi.MDB_TIMESTAMP,
 		mapi.MDB_TIMESTAMPTZ:
 		scantype = reflect.TypeOf(time.Time{})
@@ -292,7 +292,7 @@ func TestColumnTypesIntegration(t *testing.T) {
 			[]int64{0, 0},
 			[]bool{false, false},
 			[]string{"DECIMAL", "DECIMAL"},
-			[]string{"float64", "float64"},
+			[]string{"Decimal", "Decimal"},
 			[]bool{true, true},
 			[]int64{18, 10},
 			[]int64{3, 5},
@@ -421,3 +421,125 @@ func TestColumnTypesIntegration(t *testing.T) {
 	}
 	defer db.Close()
 }
+
+func TestNextResultSetIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("create local temporary table tmp_results (i int) on commit preserve rows; insert into tmp_results values (1), (2); select 1; select i from tmp_results order by i")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var counts []int
+	for {
+		count := 0
+		for rows.Next() {
+			var i int
+			if err := rows.Scan(&i); err != nil {
+				t.Fatal(err)
+			}
+			count++
+		}
+		counts = append(counts, count)
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 2 || counts[0] != 1 || counts[1] != 2 {
+		t.Errorf("Unexpected result sets %v", counts)
+	}
+}
+
+func TestNextResultSetErrorIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("select 1; select * from no_such_table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("Unexpected number of rows %d", count)
+	}
+	if rows.NextResultSet() {
+		t.Error("The failed statement has no result set")
+	}
+	if err := rows.Err(); err == nil {
+		t.Error("Expected the error of the second statement")
+	}
+}
+
+func TestRowsCloseIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	t.Run("Close a partially read result", func(t *testing.T) {
+		rows, err := db.Query("select value from sys.generate_series(0, 1000)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rows.Next() {
+			t.Fatal("query returned no rows")
+		}
+		if err := rows.Close(); err != nil {
+			t.Error(err)
+		}
+		var i int
+		if err := db.QueryRow("select 1").Scan(&i); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Read a result completely", func(t *testing.T) {
+		rows, err := db.Query("select value from sys.generate_series(0, 1000)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		count := 0
+		for rows.Next() {
+			count++
+		}
+		if err := rows.Err(); err != nil {
+			t.Error(err)
+		}
+		if count != 1000 {
+			t.Errorf("Unexpected number of rows %d", count)
+		}
+		if err := rows.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+}
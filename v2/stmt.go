@@ -24,6 +24,7 @@ func newStmt(c *Conn, q string, prepare bool) *Stmt {
 		isPreparedStatement: prepare,
 	}
 	s.resultset.Metadata.ExecId = -1
+	s.resultset.Converters = c.converters
 	s.query.Mapi = c.mapi
 	s.query.SqlQuery = q
 	return s
@@ -36,14 +37,34 @@ func executeStmt(c *Conn, query string) error {
 	return err
 }
 
+// Close releases a prepared statement on the server.
 func (s *Stmt) Close() error {
-	// TODO: check if this is correct, the pool should handle the connections
+	var err error
+	if s.conn != nil && s.isPreparedStatement {
+		err = s.conn.release(s)
+	}
 	s.conn = nil
-	return nil
+	return err
 }
 
+// release releases the prepared statement on the server. On a broken connection the
+// prepared statements are already gone.
+func (s *Stmt) release() error {
+	execId := s.resultset.Metadata.ExecId
+	if execId == -1 || s.query.Mapi == nil || !s.query.Mapi.IsConnected() {
+		return nil
+	}
+	s.resultset.Metadata.ExecId = -1
+	return checkBadConn(s.query.Mapi.ReleasePrepared(execId))
+}
+
+// NumInput returns the number of placeholders of a prepared statement. The sql package
+// then checks the number of arguments. For other statements it is not known.
 func (s *Stmt) NumInput() int {
-	return -1
+	if !s.isPreparedStatement || s.resultset.Metadata.ExecId == -1 {
+		return -1
+	}
+	return len(s.resultset.Parameters)
 }
 
 // Deprecated: Use ExecContext instead
@@ -53,32 +74,59 @@ func (s *Stmt) Exec(args []driver.Value) (driver.Result, error) {
 	return s.execResult(context.Background(), queryParams)
 }
 
-// This function executes a mapi command inside a goroutine. This makes it possible to cancel
-// the command when the context is cancelled. At this point in time MonetDB does not support cancelling
-// a running query. This feature is planned for the next release. When that comes available, we will add
-// a function call that cancels the query when a timeout occurs before it is finished.
+// This function executes the statement inside a goroutine, see run.
 func (s *Stmt) mapiDo(ctx context.Context, args []driver.NamedValue) (string, error) {
+	return s.run(ctx, func() (string, error) {
+		return s.exec(args)
+	})
+}
+
+// This function executes a mapi command inside a goroutine. This makes it possible to cancel
+// the command when the context is cancelled. The query is then stopped on the server, see cancelQuery.
+func (s *Stmt) run(ctx context.Context, command func() (string, error)) (string, error) {
 	type res struct {
 		resultstring string;
 		err error
 	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	// The session id is needed to find the query on the server when it is cancelled.
+	// It is taken before the command runs, the command can change the connection.
+	sessionId := -1
+	if s.query.Mapi != nil {
+		if id, err := s.query.Mapi.SessionId(); err == nil {
+			sessionId = id
+		}
+	}
 	c := make(chan res, 1)
+	done := make(chan struct{})
 
     go func() {
-		r, err := s.exec(args)
+		defer close(done)
+		r, err := command()
 		result := res{r, err}
 		c <- result
 		}()
 
     select {
     case <-ctx.Done():
-        <-c // Wait for the goroutine to return. Later we need to cancel the query on the database
+        cancelQuery(s.query.Mapi, sessionId, done)
         return "", ctx.Err()
     case result := <-c:
-        return result.resultstring, result.err
+        return result.resultstring, checkBadConn(result.err)
     }
 }
 
+// prepare prepares the statement on the server. The description of the placeholders
+// that the server returns is needed for NumInput.
+func (s *Stmt) prepare(ctx context.Context) error {
+	_, err := s.run(ctx, func() (string, error) {
+		return "", s.query.PrepareQuery(&s.resultset)
+	})
+	return err
+}
+
 func (s *Stmt) execResult(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
 	res := newResult()
 	r, err := s.mapiDo(ctx, args)
@@ -122,6 +170,17 @@ func paramNamesList(args []driver.NamedValue)([]string) {
 	return res
 }
 
+// namedParams reports whether the arguments are named, for a query with named placeholders
+// instead of ? placeholders
+func namedParams(args []driver.NamedValue) bool {
+	for _, arg := range args {
+		if arg.Name != "" {
+			return true
+		}
+	}
+	return false
+}
+
 func paramValuesList(args []driver.NamedValue)([]driver.Value) {
 	res := make([]driver.Value, len(args))
 	for i, arg := range args {
@@ -154,30 +213,52 @@ func (s *Stmt) Query(args []driver.Value) (driver.Rows, error) {
 }
 
 func (s *Stmt) queryResult(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
-	rows := newRows(s.conn.mapi, &s.resultset)
+	rows := newRows(ctx, s.conn.mapi, nil)
 	r, err := s.mapiDo(ctx, args)
 	if err != nil {
 		rows.err = err
 		return rows, rows.err
 	}
 
-	err = s.resultset.StoreResult(r)
-	if err != nil {
-		rows.err = err
-		return rows, rows.err
+	// The query can consist of several statements, each of them has its own result
+	var results []*mapi.ResultSet
+	for _, response := range mapi.SplitResults(r) {
+		rs := &mapi.ResultSet{Timezone: s.conn.mapi.Timezone(), Converters: s.conn.converters}
+		if err := rs.StoreResult(response); err != nil {
+			// A statement after one that returned a table failed. The error is
+			// returned by NextResultSet, after the results before it.
+			if !hasTable(results) {
+				rows.err = err
+				return rows, rows.err
+			}
+			rows.resultsErr = err
+			break
+		}
+		results = append(results, rs)
 	}
-	// We have gotten the first batch of the resultset. The RowCount is the total number of rows in the result.
-	// But we have only at most mapi.MAPI_ARRAY_SIZE rows available.
-	rows.queryId = s.resultset.Metadata.QueryId
-	rows.lastRowId = s.resultset.Metadata.LastRowId
-	rows.rowCount = s.resultset.Metadata.RowCount
-	rows.offset = s.resultset.Metadata.Offset
-	rows.rows = convertRows(s.resultset.Rows, s.resultset.Metadata.ColumnCount)
-	rows.schema = s.resultset.Schema
+	// We have gotten the first batch of each resultset. The RowCount is the total number of rows in the result.
+	// But we have only at most the reply size number of rows available.
+	rows.setResults(results)
 
 	return rows, rows.err
 }
 
+// hasTable reports whether one of the results is a table
+func hasTable(results []*mapi.ResultSet) bool {
+	for _, rs := range results {
+		if rs.Metadata.ColumnCount > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// convertParams converts the arguments for the mapi package, applying the local time
+// policy of the connection
+func (s *Stmt) convertParams(args []driver.NamedValue) []mapi.Value {
+	return s.conn.convertLocalTimes(convertParamValues(paramValuesList(args)))
+}
+
 func (s *Stmt) exec(args []driver.NamedValue) (string, error) {
 	if s.isPreparedStatement && s.resultset.Metadata.ExecId == -1 {
 		err := s.query.PrepareQuery(&s.resultset)
@@ -188,11 +269,14 @@ func (s *Stmt) exec(args []driver.NamedValue) (string, error) {
 
 	if len(args) != 0 {
 		if s.isPreparedStatement {
-			queryParams := convertParamValues(paramValuesList(args))
+			queryParams := s.convertParams(args)
 			return s.query.ExecutePreparedQuery(&s.resultset, queryParams)
+		} else if !namedParams(args) {
+			queryParams := s.convertParams(args)
+			return s.query.ExecutePositionalQuery(&s.resultset, queryParams)
 		} else {
 			queryParamsNames := paramNamesList(args)
-			queryParams := convertParamValues(paramValuesList(args))
+			queryParams := s.convertParams(args)
 			return s.query.ExecuteNamedQuery(&s.resultset, queryParamsNames, queryParams)
 		}
 	} else {
@@ -211,6 +295,25 @@ func (s *Stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driv
 }
 
 func (s *Stmt) CheckNamedValue(arg *driver.NamedValue) error {
-	_, err := mapi.ConvertToMonet(arg.Value)
-	return err
+	return checkNamedValue(arg, s.conn.converters)
+}
+
+// checkNamedValue replaces the argument with its normalized value, see mapi.NormalizeValue.
+// With a NamedValueChecker the sql package does not call driver.Valuer itself, so the
+// value of sql.Null types, named types and pointers is resolved here. The argument is
+// rejected when it cannot be converted for the server. Arguments of a type with a
+// registered encoder are passed to the encoder unchanged.
+func checkNamedValue(arg *driver.NamedValue, converters *mapi.Converters) error {
+	if _, ok := converters.Encoder(arg.Value); ok {
+		return nil
+	}
+	v, err := mapi.NormalizeValue(arg.Value)
+	if err != nil {
+		return err
+	}
+	if _, err := converters.ConvertToMonet(v); err != nil {
+		return err
+	}
+	arg.Value = v
+	return nil
 }
\ No newline at end of file
@@ -131,3 +131,50 @@ func TestStmtIntegration(t *testing.T) {
 
 	defer db.Close()
 }
+
+func TestPreparedStmtIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	t.Run("Wrong number of arguments", func(t *testing.T) {
+		stmt, err := db.Prepare("select cast(? as int) + cast(? as int)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stmt.Close()
+		if _, err := stmt.Query(1); err == nil {
+			t.Error("Query with too few arguments did not fail")
+		}
+	})
+
+	t.Run("Closed statement is released", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			stmt, err := db.Prepare("select cast(? as int)")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var v int
+			if err := stmt.QueryRow(i).Scan(&v); err != nil {
+				t.Error(err)
+			}
+			if err := stmt.Close(); err != nil {
+				t.Error(err)
+			}
+		}
+		var count int
+		if err := db.QueryRow("select count(*) from sys.prepared_statements").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("Unexpected number of prepared statements %d", count)
+		}
+	})
+}
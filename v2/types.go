@@ -0,0 +1,81 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package monetdb
+
+import (
+	"math/big"
+
+	"github.com/MonetDB/MonetDB-Go/v2/mapi"
+)
+
+// Time represents MonetDB's time datatype
+type Time = mapi.Time
+
+// Date represents MonetDB's date datatype
+type Date = mapi.Date
+
+// MonthInterval represents MonetDB's month_interval datatype, a number of
+// months. Sec_interval and day_interval columns are scanned into a
+// time.Duration.
+type MonthInterval = mapi.MonthInterval
+
+// Decimal represents MonetDB's decimal datatype, without loss of precision.
+// Decimal columns can be scanned into a Decimal, and it can be used as an
+// argument, like a *big.Rat.
+type Decimal = mapi.Decimal
+
+// HugeInt represents MonetDB's hugeint datatype, a 128 bit integer. Hugeint
+// columns can be scanned into a HugeInt, a string, or an int64 when the value
+// fits. HugeInt, *big.Int and uint64 arguments are accepted.
+type HugeInt = mapi.HugeInt
+
+// UUID represents MonetDB's uuid datatype. Uuid columns can be scanned into
+// a UUID or a string.
+type UUID = mapi.UUID
+
+// Inet represents MonetDB's inet datatype. Inet columns can be scanned into
+// an Inet or a string. Inet, netip.Prefix and netip.Addr arguments are accepted.
+type Inet = mapi.Inet
+
+// URL represents MonetDB's url datatype. Url columns can be scanned into a
+// URL or a string. URL and *url.URL arguments are accepted.
+type URL = mapi.URL
+
+// Converters holds decoders for MonetDB types and encoders for Go types, which
+// replace the built-in conversions. See Driver.Converters and
+// Connector.Converters.
+type Converters = mapi.Converters
+
+// Decoder converts the text of a value that the server sent to a Go value
+type Decoder = mapi.Decoder
+
+// Encoder converts a Go value to a literal for a query
+type Encoder = mapi.Encoder
+
+// ParseInet parses an address like "192.168.1.5" or "192.168.1.0/24"
+func ParseInet(s string) (Inet, error) {
+	return mapi.ParseInet(s)
+}
+
+// ParseUUID parses a UUID in the form "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+func ParseUUID(s string) (UUID, error) {
+	return mapi.ParseUUID(s)
+}
+
+// ParseHugeInt parses an integer like "-170141183460469231731687303715884105727"
+func ParseHugeInt(s string) (HugeInt, error) {
+	return mapi.ParseHugeInt(s)
+}
+
+// ParseDecimal parses a decimal number like "-12.340"
+func ParseDecimal(s string) (Decimal, error) {
+	return mapi.ParseDecimal(s)
+}
+
+// NewDecimal returns the decimal with the given scale that is nearest to the
+// rational number
+func NewDecimal(r *big.Rat, scale int) Decimal {
+	return mapi.NewDecimal(r, scale)
+}
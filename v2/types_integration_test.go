@@ -0,0 +1,427 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package monetdb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecimalIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run("Scan decimal without loss of precision", func(t *testing.T) {
+		var d Decimal
+		var s string
+		var f float64
+		err := db.QueryRow("select cast('1234567890123456.78' as decimal(18,2)), cast(0.1 as decimal(5,3)), cast(2.5 as decimal(3,1))").Scan(&d, &s, &f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.String() != "1234567890123456.78" {
+			t.Errorf("Unexpected decimal %s", d)
+		}
+		if s != "0.100" {
+			t.Errorf("Unexpected string %s", s)
+		}
+		if f != 2.5 {
+			t.Errorf("Unexpected float %v", f)
+		}
+	})
+
+	t.Run("Decimal arguments", func(t *testing.T) {
+		d, err := ParseDecimal("1234567890123456.78")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result, rat Decimal
+		err = db.QueryRow("select cast(? as decimal(18,2)), cast(? as decimal(5,3))", d, big.NewRat(1, 8)).Scan(&result, &rat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.String() != d.String() {
+			t.Errorf("Unexpected decimal %s", result)
+		}
+		if rat.String() != "0.125" {
+			t.Errorf("Unexpected decimal %s", rat)
+		}
+	})
+}
+
+func TestHugeintIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	large, _ := new(big.Int).SetString("170141183460469231731687303715884105727", 10)
+
+	t.Run("Scan hugeint", func(t *testing.T) {
+		var h HugeInt
+		var s string
+		var n sql.NullString
+		var i int64
+		err := db.QueryRow("select cast('170141183460469231731687303715884105727' as hugeint), cast(-5 as hugeint), cast(7 as hugeint), cast(42 as hugeint)").Scan(&h, &s, &n, &i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Int.Cmp(large) != 0 {
+			t.Errorf("Unexpected hugeint %s", h)
+		}
+		if s != "-5" {
+			t.Errorf("Unexpected string %s", s)
+		}
+		if !n.Valid || n.String != "7" {
+			t.Errorf("Unexpected null string %v", n)
+		}
+		if i != 42 {
+			t.Errorf("Unexpected int64 %d", i)
+		}
+	})
+
+	t.Run("Hugeint arguments", func(t *testing.T) {
+		var h, u, v HugeInt
+		err := db.QueryRow("select cast(? as hugeint), cast(? as hugeint), cast(? as hugeint)", large, uint64(1<<63), HugeInt{Int: large}).Scan(&h, &u, &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Int.Cmp(large) != 0 {
+			t.Errorf("Unexpected hugeint %s", h)
+		}
+		if u.String() != "9223372036854775808" {
+			t.Errorf("Unexpected hugeint %s", u)
+		}
+		if v.Int.Cmp(large) != 0 {
+			t.Errorf("Unexpected hugeint %s", v)
+		}
+	})
+
+	t.Run("Scan type of hugeint", func(t *testing.T) {
+		rows, err := db.Query("select cast(1 as hugeint)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if types[0].ScanType() != reflect.TypeOf(HugeInt{}) {
+			t.Errorf("Unexpected scan type %v", types[0].ScanType())
+		}
+	})
+}
+
+func TestUUIDIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	const s = "6c49869d-45dc-4b3f-9d6d-2a3e47ba3e0a"
+	u, err := ParseUUID(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Exec create table", func(t *testing.T) {
+		if _, err := db.Exec("create table test_uuid ( id uuid )"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Insert and scan uuid", func(t *testing.T) {
+		stmt, err := db.Prepare("insert into test_uuid values ( ? )")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stmt.Close()
+		if _, err := stmt.Exec(u); err != nil {
+			t.Fatal(err)
+		}
+		var id UUID
+		var str string
+		if err := db.QueryRow("select id, id from test_uuid where id = ?", u).Scan(&id, &str); err != nil {
+			t.Fatal(err)
+		}
+		if id != u || str != s {
+			t.Errorf("Unexpected values %s, %s", id, str)
+		}
+	})
+
+	t.Run("Scan type of uuid", func(t *testing.T) {
+		rows, err := db.Query("select id from test_uuid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if types[0].ScanType() != reflect.TypeOf(UUID{}) {
+			t.Errorf("Unexpected scan type %v", types[0].ScanType())
+		}
+	})
+
+	t.Run("Exec drop table", func(t *testing.T) {
+		if _, err := db.Exec("drop table test_uuid"); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestJSONIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	type event struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	t.Run("Exec create table", func(t *testing.T) {
+		if _, err := db.Exec("create table test_json ( payload json )"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Insert and scan json", func(t *testing.T) {
+		if _, err := db.Exec("insert into test_json values ( ? )", event{"it's \"quoted\"", 2}); err != nil {
+			t.Fatal(err)
+		}
+		var payload json.RawMessage
+		if err := db.QueryRow("select payload from test_json").Scan(&payload); err != nil {
+			t.Fatal(err)
+		}
+		var e event
+		if err := json.Unmarshal(payload, &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Name != "it's \"quoted\"" || e.Count != 2 {
+			t.Errorf("Unexpected payload %s", payload)
+		}
+	})
+
+	t.Run("Scan type of json", func(t *testing.T) {
+		rows, err := db.Query("select payload from test_json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if types[0].ScanType() != reflect.TypeOf(json.RawMessage{}) {
+			t.Errorf("Unexpected scan type %v", types[0].ScanType())
+		}
+	})
+
+	t.Run("Exec drop table", func(t *testing.T) {
+		if _, err := db.Exec("drop table test_json"); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestInetURLIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	network := netip.MustParsePrefix("192.168.1.0/24")
+	location, err := url.Parse("https://www.monetdb.org/Documentation")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Exec create table", func(t *testing.T) {
+		if _, err := db.Exec("create table test_inet ( network inet, address inet, location url )"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Insert and scan inet and url", func(t *testing.T) {
+		_, err := db.Exec("insert into test_inet values ( ?, ?, ? )", network, network.Addr(), location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var n, a Inet
+		var u URL
+		if err := db.QueryRow("select network, address, location from test_inet").Scan(&n, &a, &u); err != nil {
+			t.Fatal(err)
+		}
+		if n.Prefix != network || a.Addr() != network.Addr() || u.String() != location.String() {
+			t.Errorf("Unexpected values %s, %s, %s", n, a, u.String())
+		}
+	})
+
+	t.Run("Scan type of inet and url", func(t *testing.T) {
+		rows, err := db.Query("select network, location from test_inet")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if types[0].ScanType() != reflect.TypeOf(Inet{}) || types[1].ScanType() != reflect.TypeOf(URL{}) {
+			t.Errorf("Unexpected scan types %v, %v", types[0].ScanType(), types[1].ScanType())
+		}
+	})
+
+	t.Run("Exec drop table", func(t *testing.T) {
+		if _, err := db.Exec("drop table test_inet"); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestIntervalIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run("Scan intervals", func(t *testing.T) {
+		var seconds, days time.Duration
+		var months MonthInterval
+		query := "select interval '90' minute, date '2001-01-03' - date '2001-01-01', interval '1-2' year to month"
+		if err := db.QueryRow(query).Scan(&seconds, &days, &months); err != nil {
+			t.Fatal(err)
+		}
+		if seconds != 90*time.Minute || days != 48*time.Hour || months != 14 {
+			t.Errorf("Unexpected intervals %v, %v, %s", seconds, days, months)
+		}
+	})
+
+	t.Run("Interval arguments", func(t *testing.T) {
+		var ts time.Time
+		var d Date
+		query := "select timestamp '2001-01-01 00:00:00' + ?, date '2001-01-31' + ?"
+		if err := db.QueryRow(query, 1500*time.Millisecond, MonthInterval(1)).Scan(&ts, &d); err != nil {
+			t.Fatal(err)
+		}
+		if ts.Nanosecond() != 500000000 || ts.Second() != 1 {
+			t.Errorf("Unexpected timestamp %v", ts)
+		}
+		if d.String() != "2001-02-28" {
+			t.Errorf("Unexpected date %s", d)
+		}
+	})
+
+	t.Run("Scan type of intervals", func(t *testing.T) {
+		rows, err := db.Query("select interval '1' second, interval '1' month")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if types[0].ScanType() != reflect.TypeOf(time.Duration(0)) || types[1].ScanType() != reflect.TypeOf(MonthInterval(0)) {
+			t.Errorf("Unexpected scan types %v, %v", types[0].ScanType(), types[1].ScanType())
+		}
+	})
+}
+
+func TestTemporalPrecisionIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	db, err := sql.Open("monetdb", "monetdb:monetdb@localhost:50000/monetdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	t.Run("Scan fractions of seconds", func(t *testing.T) {
+		var tm Time
+		var ts time.Time
+		query := "select cast('10:20:30.123456' as time(6)), cast('2001-01-02 10:20:30.000001' as timestamp(6))"
+		if err := db.QueryRow(query).Scan(&tm, &ts); err != nil {
+			t.Fatal(err)
+		}
+		if tm.Nsec != 123456000 || ts.Nanosecond() != 1000 {
+			t.Errorf("Unexpected values %s, %v", tm, ts)
+		}
+	})
+
+	t.Run("Scan offsets of time zones", func(t *testing.T) {
+		var tm, ts time.Time
+		query := "select cast('10:20:30+02:00' as timetz), cast('2001-01-02 10:20:30.5-05:30' as timestamptz)"
+		if err := db.QueryRow(query).Scan(&tm, &ts); err != nil {
+			t.Fatal(err)
+		}
+		// The server returns the values in the time zone of the session
+		if !ts.Equal(time.Date(2001, time.January, 2, 16, 0, 30, 500000000, time.UTC)) {
+			t.Errorf("Unexpected timestamp %v", ts)
+		}
+		if tm.UTC().Hour() != 8 || tm.UTC().Minute() != 20 {
+			t.Errorf("Unexpected time %v", tm)
+		}
+	})
+
+	t.Run("Scan type of timetz", func(t *testing.T) {
+		rows, err := db.Query("select cast('10:20:30+02:00' as timetz)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if types[0].ScanType() != reflect.TypeOf(time.Time{}) {
+			t.Errorf("Unexpected scan type %v", types[0].ScanType())
+		}
+	})
+}